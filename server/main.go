@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // users' time zones, for quiet hours and digests
 
 	"ventapp/server/ventapp/config"
 	"ventapp/server/ventapp/controllers"
 	authControllers "ventapp/server/ventapp/controllers"
+	"ventapp/server/ventapp/mail"
 	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/ventapp/telegram"
+	"ventapp/server/websocket"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +30,33 @@ func main() {
 	if port := os.Getenv("PORT"); port != "" {
 		cfg.Port = port
 	}
+	if path := os.Getenv("CRISIS_CONFIG"); path != "" {
+		crisis, err := config.LoadCrisisConfig(path)
+		if err != nil {
+			log.Fatalf("failed to load crisis config: %v", err)
+		}
+		cfg.Crisis = crisis
+	}
+	if path := os.Getenv("TAG_SYNONYMS"); path != "" {
+		synonyms, err := config.LoadTagSynonyms(path)
+		if err != nil {
+			log.Fatalf("failed to load tag synonyms: %v", err)
+		}
+		for from, to := range synonyms {
+			cfg.Tags.Synonyms[from] = to
+		}
+	}
+	if url := os.Getenv("APP_URL"); url != "" {
+		cfg.Notify.AppURL = url
+	}
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		cfg.Notify.MailFrom = from
+	}
+	cfg.Telegram.BotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+	cfg.Telegram.WebhookSecret = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	if url := os.Getenv("TELEGRAM_API_URL"); url != "" {
+		cfg.Telegram.APIURL = url
+	}
 
 	// connect DB
 	if err := config.Connect(cfg.MongoURI, cfg.DBName); err != nil {
@@ -25,6 +64,56 @@ func main() {
 	}
 	defer config.Disconnect()
 
+	if err := repositories.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to ensure indexes: %v", err)
+	}
+
+	services.Configure(cfg)
+	if err := services.Migrate(context.Background()); err != nil {
+		log.Fatalf("failed to migrate data: %v", err)
+	}
+	if err := services.Filters.Seed(context.Background()); err != nil {
+		log.Fatalf("failed to seed filter rules: %v", err)
+	}
+	if err := services.Filters.Load(context.Background()); err != nil {
+		log.Fatalf("failed to load filter rules: %v", err)
+	}
+
+	// background jobs and the server stop when the process is asked to
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go services.RunTrendingJob(ctx)
+	// view counts are flushed once more after the server stops taking
+	// requests, so none are lost on shutdown
+	viewsCtx, stopViews := context.WithCancel(context.Background())
+	viewsDone := make(chan struct{})
+	go func() {
+		defer close(viewsDone)
+		services.Views.Run(viewsCtx)
+	}()
+	go services.Filters.Run(ctx)
+
+	// email goes to files in MAIL_DIR when set, e.g. in development, and
+	// otherwise through SMTP_ADDR; without either no email is sent
+	if dir := os.Getenv("MAIL_DIR"); dir != "" {
+		services.UseMailer(mail.FileMailer{Dir: dir})
+	} else if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		services.UseMailer(mail.SMTPMailer{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+	}
+	go services.RunDigestJob(ctx)
+	go services.RunReleaseJob(ctx)
+	if cfg.Telegram.BotToken != "" {
+		services.UseTelegram(telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.BotToken))
+	}
+
+	hub := websocket.NewHub()
+	go hub.Run()
+	controllers.SetHub(hub)
+
 	r := gin.Default()
 
 	// attach JWT middleware globally (it will be permissive: allows anonymous)
@@ -40,13 +129,164 @@ func main() {
 	// Posts (vents) routes
 	posts := r.Group("/posts")
 	{
-		posts.POST("/", controllers.CreateVent)
+		posts.POST("/", middleware.RequireAuth(), controllers.CreateVent)
 		posts.GET("/", controllers.GetVents)
+		posts.GET("/:id", controllers.GetVent)
+		posts.PATCH("/:id", middleware.RequireAuth(), controllers.UpdateVent)
+		posts.DELETE("/:id", middleware.RequireAuth(), controllers.DeleteVent)
+		posts.PUT("/:id/vote", middleware.RequireAuth(), controllers.VoteVent)
+		posts.POST("/:id/replies", middleware.RequireAuth(), controllers.CreateReply)
+		posts.GET("/:id/replies", controllers.GetReplies)
+		posts.POST("/:id/accept", middleware.RequireAuth(), controllers.AcceptAnswer)
+		posts.DELETE("/:id/accept", middleware.RequireAuth(), controllers.ClearAcceptedAnswer)
+		posts.POST("/:id/save", middleware.RequireAuth(), controllers.SaveVent)
+		posts.DELETE("/:id/save", middleware.RequireAuth(), controllers.UnsaveVent)
+		posts.POST("/:id/report", middleware.RequireAuth(), controllers.ReportVent)
+		posts.PUT("/:id/hide", middleware.RequireAuth(), controllers.HideVent)
+		posts.DELETE("/:id/hide", middleware.RequireAuth(), controllers.UnhideVent)
+	}
+
+	// Current user routes
+	me := r.Group("/me", middleware.RequireAuth())
+	{
+		me.GET("/saved", controllers.GetSavedVents)
+		me.GET("/saved/folders", controllers.GetSaveFolders)
+		me.GET("/moderation", controllers.GetMyModeration)
+		me.GET("/tags", controllers.GetFollowedTags)
+		me.PUT("/courses", controllers.SetCourses)
+		me.PUT("/academic", controllers.SetAcademic)
+		me.GET("/blocks", controllers.GetRelations)
+		me.GET("/muted-tags", controllers.GetMutedTags)
+		me.GET("/hidden", controllers.GetHiddenVents)
+		me.GET("/notification-settings", controllers.GetNotificationSettings)
+		me.PUT("/notification-settings", controllers.UpdateNotificationSettings)
+	}
+
+	// Block and mute routes
+	users := r.Group("/users", middleware.RequireAuth())
+	{
+		users.PUT("/:id/block", controllers.BlockUser)
+		users.DELETE("/:id/block", controllers.UnblockUser)
+		users.PUT("/:id/mute", controllers.MuteUser)
+		users.DELETE("/:id/mute", controllers.UnmuteUser)
+	}
+
+	r.POST("/moderation/:id/appeal", middleware.RequireAuth(), controllers.FileAppeal)
+
+	// Replies routes
+	replies := r.Group("/replies", middleware.RequireAuth())
+	{
+		replies.PATCH("/:id", controllers.UpdateReply)
+		replies.DELETE("/:id", controllers.DeleteReply)
+		replies.PUT("/:id/vote", controllers.VoteReply)
+		replies.POST("/:id/report", controllers.ReportReply)
+	}
+
+	// Home feed routes
+	feed := r.Group("/feed", middleware.RequireAuth())
+	{
+		feed.GET("/home", controllers.GetHomeFeed)
+		feed.POST("/seen", controllers.MarkSeen)
+	}
+
+	// Notification routes
+	notifications := r.Group("/notifications", middleware.RequireAuth())
+	{
+		notifications.GET("/", controllers.GetNotifications)
+		notifications.GET("/unread-count", controllers.GetUnreadNotifications)
+		notifications.POST("/read-all", controllers.MarkAllNotificationsRead)
+		notifications.POST("/:id/read", controllers.MarkNotificationRead)
+	}
+
+	r.GET("/search", controllers.Search)
+
+	// Tag routes
+	tags := r.Group("/tags")
+	{
+		tags.GET("/", controllers.GetTags)
+		tags.GET("/:name", controllers.GetTag)
+		tags.PUT("/:name/follow", middleware.RequireAuth(), controllers.FollowTag)
+		tags.DELETE("/:name/follow", middleware.RequireAuth(), controllers.UnfollowTag)
+		tags.PUT("/:name/mute", middleware.RequireAuth(), controllers.MuteTag)
+		tags.DELETE("/:name/mute", middleware.RequireAuth(), controllers.UnmuteTag)
 	}
 
-	addr := ":" + cfg.Port
-	log.Printf("starting server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("server failed: %v", err)
+	r.GET("/ws", controllers.ServeWS)
+	r.POST("/telegram/webhook", controllers.TelegramWebhook)
+
+	// Admin routes
+	admin := r.Group("/admin", middleware.RequireAuth())
+	{
+		roles := admin.Group("/roles", middleware.RequirePermission(models.PermManageRoles))
+		{
+			roles.POST("/", controllers.GrantRole)
+			roles.DELETE("/:id", controllers.RevokeRole)
+		}
+		admin.GET("/roles/audit", middleware.RequirePermission(models.PermViewAudit), controllers.GetRoleAudit)
+		admin.GET("/users/:id/roles", middleware.RequirePermission(models.PermManageRoles), controllers.GetUserRoles)
+
+		manageUsers := middleware.RequirePermission(models.PermManageUsers)
+		admin.POST("/users/:id/sanctions", manageUsers, controllers.IssueSanction)
+		admin.GET("/users/:id/sanctions", manageUsers, controllers.GetUserSanctions)
+		admin.DELETE("/sanctions/:id", manageUsers, controllers.LiftSanction)
+
+		adminPosts := admin.Group("/posts", middleware.RequirePermission(models.PermModerateContent))
+		{
+			adminPosts.GET("/:id/revisions", controllers.GetVentRevisions)
+			adminPosts.POST("/:id/restore", controllers.RestoreVent)
+		}
+
+		reports := admin.Group("/reports", middleware.RequirePermission(models.PermViewReports))
+		{
+			reports.GET("/", controllers.GetReportQueue)
+			reports.GET("/:target_type/:target_id", controllers.GetTargetReports)
+			reports.POST("/:target_type/:target_id/resolve", controllers.ResolveReports)
+			reports.POST("/:target_type/:target_id/dismiss", controllers.DismissReports)
+			reports.POST("/:target_type/:target_id/restore", controllers.RestoreContent)
+		}
+
+		admin.GET("/moderation", middleware.RequirePermission(models.PermViewAudit), controllers.GetModerationLog)
+
+		filters := admin.Group("/filters", middleware.RequirePermission(models.PermModerateContent))
+		{
+			filters.GET("/", controllers.GetFilterRules)
+			filters.POST("/", controllers.CreateFilterRule)
+			filters.POST("/test", controllers.TestFilter)
+			filters.PATCH("/:id", controllers.UpdateFilterRule)
+			filters.DELETE("/:id", controllers.DeleteFilterRule)
+		}
+
+		adminTags := admin.Group("/tags", middleware.RequirePermission(models.PermModerateContent))
+		{
+			adminTags.PATCH("/:name", controllers.UpdateTag)
+			adminTags.POST("/:name/ban", controllers.BanTag)
+			adminTags.DELETE("/:name/ban", controllers.UnbanTag)
+			adminTags.POST("/:name/merge", controllers.MergeTag)
+		}
+
+		appeals := admin.Group("/appeals", middleware.RequireAnyPermission(models.PermModerateContent, models.PermManageUsers))
+		{
+			appeals.GET("/", controllers.GetAppeals)
+			appeals.POST("/:id/accept", controllers.AcceptAppeal)
+			appeals.POST("/:id/reject", controllers.RejectAppeal)
+		}
+	}
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		log.Printf("starting server on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown failed: %v", err)
 	}
+	stopViews()
+	<-viewsDone
 }
@@ -9,6 +9,7 @@ import (
 
 	"ventapp/server/ventapp/config"
 	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -55,21 +56,24 @@ func RunSeed() {
 		v := models.Vent{
 			ID:        primitive.NewObjectID(),
 			AuthorID:  userIDs[rand.Intn(len(userIDs))],
+			Kind:      models.KindVent,
 			Content:   fmt.Sprintf("Sample vent content #%d", i+1),
 			Tags:      tags[rand.Intn(len(tags))],
 			Upvotes:   rand.Intn(30),
 			Downvotes: rand.Intn(10),
 			Views:     rand.Intn(100),
-			SavedBy:   []primitive.ObjectID{},
-			Reports:   []primitive.ObjectID{},
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			IsDeleted: false,
 		}
+		v.Score = v.Upvotes - v.Downvotes
 		if _, err := ventCol.InsertOne(context.Background(), v); err != nil {
 			log.Printf("failed to insert vent: %v", err)
 			continue
 		}
+		if err := services.RefreshHotScore(context.Background(), v.ID); err != nil {
+			log.Printf("failed to score vent: %v", err)
+		}
 	}
 
 	fmt.Printf("Inserted %d users and 10 vents\n", len(userIDs))
@@ -2,9 +2,13 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"os"
 	"time"
 
+	"ventapp/server/ventapp/telegram"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -46,6 +50,219 @@ type AppConfig struct {
 	MongoURI string
 	DBName   string
 	Port     string
+	Ranking  RankingConfig
+	Views    ViewConfig
+	// EditWindow is how long after posting an author may edit a vent. Zero
+	// means vents stay editable.
+	EditWindow time.Duration
+	Replies    ReplyConfig
+	Moderation ModerationConfig
+	Filters    FilterConfig
+	Crisis     CrisisConfig
+	Spam       SpamConfig
+	Search     SearchConfig
+	Tags       TagConfig
+	Feed       FeedConfig
+	Notify     NotifyConfig
+	Telegram   TelegramConfig
+}
+
+// RankingConfig tunes the hot and trending feed sorts.
+type RankingConfig struct {
+	// HotGravity is how many seconds of age it takes to outweigh a tenfold
+	// difference in net votes. Lower values make the hot feed turn over faster.
+	HotGravity float64
+	// ReplyWeight is how many net votes a single reply is worth in the hot score.
+	ReplyWeight float64
+	// TrendingWindow is how far back vote velocity is measured.
+	TrendingWindow time.Duration
+	// TrendingBucket is the granularity vote activity is counted in.
+	TrendingBucket time.Duration
+	// TrendingInterval is how often trending scores are recomputed.
+	TrendingInterval time.Duration
+}
+
+// ViewConfig tunes vent view counting.
+type ViewConfig struct {
+	// Window is how long a viewer's repeat visits to a vent are not counted again.
+	Window time.Duration
+	// FlushInterval is how often buffered view counts are written to the database.
+	FlushInterval time.Duration
+}
+
+// ReplyConfig bounds reply threads.
+type ReplyConfig struct {
+	// MaxDepth is the deepest a reply may be nested; top-level replies are depth 0.
+	MaxDepth int
+	// DefaultViewDepth and MaxViewDepth bound how many levels a single
+	// GET returns before clients must load a branch on its own.
+	DefaultViewDepth int
+	MaxViewDepth     int
+	// ChildLimit is how many replies are returned per branch below the top level.
+	ChildLimit int64
+	// MaxTreeNodes caps how many replies below the top level one request
+	// loads; branches past the cap are left for clients to load on their own.
+	MaxTreeNodes int
+}
+
+// ModerationConfig tunes automatic hiding of reported content.
+type ModerationConfig struct {
+	// AutoHideReports hides a vent or reply once it has more than this many
+	// distinct reports. Zero disables the rule.
+	AutoHideReports int
+	// AutoHideWeight hides a vent or reply once the combined weight of
+	// reports from trusted users exceeds it. Zero disables the rule.
+	AutoHideWeight float64
+	// TrustedReportWeight is what one report from a trusted user weighs.
+	TrustedReportWeight float64
+	// TrustedAccountAge is how old an account must be for its reports to be
+	// trusted. Users who can view reports are always trusted.
+	TrustedAccountAge time.Duration
+}
+
+// FilterConfig tunes the content filter.
+type FilterConfig struct {
+	// ReloadInterval is how often filter rules are reloaded from the
+	// database, so edits made through another server take effect.
+	ReloadInterval time.Duration
+}
+
+// SpamConfig tunes posting rate limits and duplicate detection.
+type SpamConfig struct {
+	// Vents and Replies limit how fast established users may post.
+	Vents   RateLimit
+	Replies RateLimit
+	// NewAccountAge is how long an account is held to the stricter
+	// NewVents and NewReplies limits.
+	NewAccountAge time.Duration
+	NewVents      RateLimit
+	NewReplies    RateLimit
+	// MaxTags is the most tags a vent may carry.
+	MaxTags int
+	// DuplicateDistance is the largest number of differing simhash bits at
+	// which two posts count as near-duplicates. Negative disables detection.
+	DuplicateDistance int
+	// AuthorWindow and AuthorRecent bound which of the author's own posts a
+	// new post is compared with; a near-duplicate of one is rejected.
+	AuthorWindow time.Duration
+	AuthorRecent int64
+	// GlobalWindow and GlobalRecent bound which posts by other users a new
+	// post is compared with; a near-duplicate of one is held for review.
+	// Posts shorter than GlobalMinWords are not compared globally, and only
+	// with the author's own replies on the same vent, since short phrases
+	// are often posted independently.
+	GlobalWindow   time.Duration
+	GlobalRecent   int64
+	GlobalMinWords int
+}
+
+// RateLimit is a token bucket: Burst posts may be made at once, and the
+// allowance refills at PerHour posts an hour. Zero PerHour disables it.
+type RateLimit struct {
+	PerHour float64
+	Burst   int
+}
+
+// SearchConfig tunes search ranking.
+type SearchConfig struct {
+	// RecencyGravity is how much newer a post must be to weigh e times as
+	// much as an equally relevant older one.
+	RecencyGravity time.Duration
+}
+
+// TagConfig tunes how vent tags are normalised.
+type TagConfig struct {
+	// MaxLength is the most characters a tag keeps after normalisation.
+	MaxLength int
+	// Synonyms maps tags to the tag they are stored as, e.g. plurals to
+	// their singular. Tags merged by an admin are mapped the same way.
+	// Campus slang such as "hw" lives in the TAG_SYNONYMS file, which adds
+	// to these.
+	Synonyms map[string]string
+}
+
+// FeedConfig tunes the personalised home feed.
+type FeedConfig struct {
+	// Weights boost vents related to the reader. They are added to the hot
+	// score scaled by Global, so with Global at 1 a weight of 1 counts as
+	// much as ten times the votes or HotGravity seconds of freshness; a vent
+	// matching several ways gets each boost.
+	Weights FeedWeights
+	// Window is how far back the feed looks for vents.
+	Window time.Duration
+	// Size is how many vents one feed session ranks; scrolling past them
+	// starts a new session.
+	Size int64
+	// SessionTTL is how long a ranked feed can be paged through.
+	SessionTTL time.Duration
+	// SeenTTL is how long a vent the reader has seen stays out of their feed.
+	SeenTTL time.Duration
+}
+
+// FeedWeights are the home feed boosts for each way a vent can relate to the
+// reader. Global scales the hot score of every vent, related or not; below 1
+// it favours related vents over globally hot ones.
+type FeedWeights struct {
+	Tag        float64
+	Course     float64
+	Department float64
+	University float64
+	Global     float64
+}
+
+// NotifyConfig tunes notification delivery outside the app and email
+// digests.
+type NotifyConfig struct {
+	// AppURL is the address of the web client that links in emails point to.
+	AppURL string
+	// MailFrom is the sender of notification emails and digests.
+	MailFrom string
+	// Timezone is the IANA time zone quiet hours and digests follow for
+	// users who have not chosen one.
+	Timezone string
+	// DigestHour is the local hour digests are sent at; weekly digests go
+	// out on DigestWeekday.
+	DigestHour    int
+	DigestWeekday time.Weekday
+	// DigestInterval is how often the digest job looks for users due one.
+	DigestInterval time.Duration
+	// DigestTrending is how many trending vents from the reader's department
+	// a digest lists.
+	DigestTrending int64
+	// ReleaseInterval is how often notifications held during quiet hours
+	// are looked for once their quiet hours end.
+	ReleaseInterval time.Duration
+	// SendQueue is how many notifications each channel outside the app
+	// queues while it is busy; past it, new ones are dropped.
+	SendQueue int
+}
+
+// TelegramConfig sets up the bot that sends notifications over Telegram.
+type TelegramConfig struct {
+	// BotToken is empty when there is no bot. APIURL is the Bot API address.
+	BotToken string
+	APIURL   string
+	// WebhookSecret is the secret token Telegram sends with every update it
+	// delivers to the webhook.
+	WebhookSecret string
+	// MessageGap spaces out the bot's messages overall and ChatGap those to
+	// one chat, to stay under the Bot API's rate limits.
+	MessageGap time.Duration
+	ChatGap    time.Duration
+	// MaxRetryAfter is the longest the bot waits out a rate limit the API
+	// reports; messages that would wait longer are dropped.
+	MaxRetryAfter time.Duration
+}
+
+// LoadTagSynonyms reads a JSON object of tag synonyms from a local file.
+func LoadTagSynonyms(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var synonyms map[string]string
+	err = json.Unmarshal(raw, &synonyms)
+	return synonyms, err
 }
 
 func DefaultConfig() AppConfig {
@@ -53,5 +270,95 @@ func DefaultConfig() AppConfig {
 		MongoURI: "mongodb://localhost:27017",
 		DBName:   "ventapp",
 		Port:     "8080",
+		Ranking: RankingConfig{
+			HotGravity:       45000,
+			ReplyWeight:      0.5,
+			TrendingWindow:   6 * time.Hour,
+			TrendingBucket:   10 * time.Minute,
+			TrendingInterval: 5 * time.Minute,
+		},
+		Views: ViewConfig{
+			Window:        30 * time.Minute,
+			FlushInterval: 10 * time.Second,
+		},
+		EditWindow: 24 * time.Hour,
+		Replies: ReplyConfig{
+			MaxDepth:         8,
+			DefaultViewDepth: 3,
+			MaxViewDepth:     6,
+			ChildLimit:       5,
+			MaxTreeNodes:     500,
+		},
+		Moderation: ModerationConfig{
+			AutoHideReports:     5,
+			AutoHideWeight:      3,
+			TrustedReportWeight: 2,
+			TrustedAccountAge:   90 * 24 * time.Hour,
+		},
+		Filters: FilterConfig{
+			ReloadInterval: time.Minute,
+		},
+		Crisis: DefaultCrisisConfig(),
+		Spam: SpamConfig{
+			Vents:             RateLimit{PerHour: 10, Burst: 5},
+			Replies:           RateLimit{PerHour: 60, Burst: 20},
+			NewAccountAge:     3 * 24 * time.Hour,
+			NewVents:          RateLimit{PerHour: 2, Burst: 2},
+			NewReplies:        RateLimit{PerHour: 15, Burst: 5},
+			MaxTags:           5,
+			DuplicateDistance: 10,
+			AuthorWindow:      7 * 24 * time.Hour,
+			AuthorRecent:      50,
+			GlobalWindow:      time.Hour,
+			GlobalRecent:      200,
+			GlobalMinWords:    8,
+		},
+		Search: SearchConfig{
+			RecencyGravity: 30 * 24 * time.Hour,
+		},
+		Tags: TagConfig{
+			MaxLength: 32,
+			Synonyms: map[string]string{
+				"exams":       "exam",
+				"midterms":    "midterm",
+				"finals":      "final",
+				"assignments": "assignment",
+				"dorms":       "dorm",
+				"roommates":   "roommate",
+				"grades":      "grade",
+				"professors":  "professor",
+				"lecturers":   "lecturer",
+			},
+		},
+		Feed: FeedConfig{
+			Weights: FeedWeights{
+				Tag:        2,
+				Course:     2,
+				Department: 1.5,
+				University: 1,
+				Global:     1,
+			},
+			Window:     14 * 24 * time.Hour,
+			Size:       500,
+			SessionTTL: time.Hour,
+			SeenTTL:    30 * 24 * time.Hour,
+		},
+		Notify: NotifyConfig{
+			AppURL:          "http://localhost:5173",
+			MailFrom:        "UniQ&A <no-reply@localhost>",
+			Timezone:        "Africa/Addis_Ababa",
+			DigestHour:      18,
+			DigestWeekday:   time.Sunday,
+			DigestInterval:  15 * time.Minute,
+			DigestTrending:  5,
+			ReleaseInterval: time.Minute,
+			SendQueue:       1000,
+		},
+		Telegram: TelegramConfig{
+			APIURL:        telegram.DefaultAPIURL,
+			MessageGap:    35 * time.Millisecond,
+			ChatGap:       time.Second,
+			MaxRetryAfter: 30 * time.Second,
+		},
 	}
 }
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// CrisisConfig tunes detection of posts that signal self-harm and the
+// support shown to their authors. It can be replaced by a local JSON file
+// with LoadCrisisConfig; nothing is sent to outside services.
+type CrisisConfig struct {
+	// Threshold is the score at which a post is treated as a crisis. Zero
+	// disables detection.
+	Threshold float64 `json:"threshold"`
+	// Lexicon is scored against each post: every phrase found adds its
+	// weight once. Phrases match whole words after case and look-alike
+	// folding, in any script; list transliterations as phrases of their own.
+	// Negative weights offset phrases common in safe contexts.
+	Lexicon []CrisisTerm `json:"lexicon"`
+	// Message is shown to the author above the resources.
+	Message string `json:"message"`
+	// Resources are offered to the author: a university's own resources come
+	// first, followed by those for its country, or Default when the country
+	// has none.
+	Resources CrisisResources `json:"resources"`
+}
+
+// CrisisTerm is one weighted lexicon entry.
+type CrisisTerm struct {
+	Phrase string  `json:"phrase"`
+	Weight float64 `json:"weight"`
+}
+
+// CrisisResources maps where a post was made to the help offered.
+// Universities are keyed by id, countries by ISO 3166-1 alpha-2 code.
+type CrisisResources struct {
+	Default      []CrisisResource            `json:"default"`
+	Countries    map[string][]CrisisResource `json:"countries"`
+	Universities map[string][]CrisisResource `json:"universities"`
+}
+
+// CrisisResource is one place to get help.
+type CrisisResource struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// LoadCrisisConfig reads a crisis configuration from a local JSON file.
+// Fields the file leaves out keep their defaults.
+func LoadCrisisConfig(path string) (CrisisConfig, error) {
+	cfg := DefaultCrisisConfig()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(raw, &cfg)
+	return cfg, err
+}
+
+func DefaultCrisisConfig() CrisisConfig {
+	return CrisisConfig{
+		Threshold: 3,
+		Lexicon: []CrisisTerm{
+			{Phrase: "kill myself", Weight: 3},
+			{Phrase: "killing myself", Weight: 3},
+			{Phrase: "end my life", Weight: 3},
+			{Phrase: "want to die", Weight: 3},
+			{Phrase: "wanna die", Weight: 3},
+			{Phrase: "better off dead", Weight: 3},
+			{Phrase: "no reason to live", Weight: 2},
+			{Phrase: "suicide", Weight: 2},
+			{Phrase: "suicidal", Weight: 2},
+			{Phrase: "self harm", Weight: 2},
+			{Phrase: "self-harm", Weight: 2},
+			{Phrase: "cut myself", Weight: 2},
+			{Phrase: "cutting myself", Weight: 2},
+			{Phrase: "hurt myself", Weight: 2},
+			{Phrase: "overdose", Weight: 1},
+			{Phrase: "hopeless", Weight: 1},
+			{Phrase: "can't go on", Weight: 1},
+			{Phrase: "suicide prevention", Weight: -2},
+			{Phrase: "not suicidal", Weight: -2},
+			// Amharic and common transliterations
+			{Phrase: "ራሴን ማጥፋት", Weight: 3},
+			{Phrase: "ራሴን ላጠፋ", Weight: 3},
+			{Phrase: "ራስን ማጥፋት", Weight: 2},
+			{Phrase: "መሞት እፈልጋለሁ", Weight: 3},
+			{Phrase: "መኖር አልፈልግም", Weight: 3},
+			{Phrase: "rasen matfat", Weight: 3},
+			{Phrase: "mot efelgalehu", Weight: 3},
+			{Phrase: "menor alfelgim", Weight: 3},
+		},
+		Message: "You're not alone. If you're thinking about hurting yourself, please reach out to someone now.",
+		Resources: CrisisResources{
+			Default: []CrisisResource{
+				{Name: "Find a Helpline", URL: "https://findahelpline.com", Description: "Free, confidential crisis lines in your country."},
+			},
+			Countries: map[string][]CrisisResource{
+				"ET": {
+					{Name: "Ambulance", Phone: "907"},
+					{Name: "Police", Phone: "991"},
+					{Name: "Find a Helpline", URL: "https://findahelpline.com", Description: "Free, confidential crisis lines in Ethiopia."},
+				},
+			},
+		},
+	}
+}
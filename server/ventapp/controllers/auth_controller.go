@@ -6,14 +6,18 @@ import (
 	"time"
 
 	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/middleware"
 	"ventapp/server/ventapp/models"
 	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
 	"ventapp/server/ventapp/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var userRepo = repositories.NewUserRepository()
+
 // TODO: Implement Telegram Web auth flow. This controller will accept a Telegram auth payload
 // (signed data), validate it, create or find a user, and return a session token (or set cookie).
 
@@ -90,6 +94,16 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	st, err := services.UserStanding(context.Background(), u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check account standing"})
+		return
+	}
+	if st.Ban != nil {
+		c.JSON(http.StatusForbidden, middleware.BannedResponse(st.Ban))
+		return
+	}
+
 	token, _ := config.GenerateToken(u.ID.Hex(), time.Hour*24)
 	c.JSON(http.StatusOK, gin.H{"token": token, "user": u})
 }
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BlockUser - PUT /users/:id/block
+func BlockUser(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.BlockUser(context.Background(), userID, targetID); err != nil {
+		respondServiceError(c, err, "failed to block user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnblockUser - DELETE /users/:id/block
+func UnblockUser(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.UnblockUser(context.Background(), userID, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unblock user"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MuteUser - PUT /users/:id/mute
+func MuteUser(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.MuteUser(context.Background(), userID, targetID); err != nil {
+		respondServiceError(c, err, "failed to mute user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnmuteUser - DELETE /users/:id/mute
+func UnmuteUser(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.UnmuteUser(context.Background(), userID, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unmute user"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetRelations - GET /me/blocks?kind=
+// kind is block or mute; both are listed when it is empty.
+func GetRelations(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	kind := c.Query("kind")
+	if kind != "" && kind != models.RelationBlock && kind != models.RelationMute {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind"})
+		return
+	}
+	relations, err := services.Relations(context.Background(), userID, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch blocked users"})
+		return
+	}
+	c.JSON(http.StatusOK, relations)
+}
+
+// MuteTag - PUT /tags/:name/mute
+func MuteTag(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	if err := services.MuteTag(context.Background(), userID, c.Param("name")); err != nil {
+		respondServiceError(c, err, "failed to mute tag")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnmuteTag - DELETE /tags/:name/mute
+func UnmuteTag(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	if err := services.UnmuteTag(context.Background(), userID, c.Param("name")); err != nil {
+		respondServiceError(c, err, "failed to unmute tag")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetMutedTags - GET /me/muted-tags
+func GetMutedTags(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	muted, err := services.MutedTags(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch muted tags"})
+		return
+	}
+	c.JSON(http.StatusOK, muted)
+}
+
+// HideVent - PUT /posts/:id/hide
+func HideVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.HideVent(context.Background(), userID, ventID); err != nil {
+		respondServiceError(c, err, "failed to hide vent")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnhideVent - DELETE /posts/:id/hide
+func UnhideVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := services.UnhideVent(context.Background(), userID, ventID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unhide vent"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetHiddenVents - GET /me/hidden
+func GetHiddenVents(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	hidden, err := services.HiddenVents(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch hidden vents"})
+		return
+	}
+	c.JSON(http.StatusOK, hidden)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHomeFeed - GET /feed/home?cursor=&limit=
+// The first page ranks a fresh feed; follow next_cursor to keep scrolling it.
+// An expired cursor is rejected and the client should start over.
+func GetHomeFeed(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := services.HomeFeed(context.Background(), userID, after, limit)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch feed")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// MarkSeenRequest - payload listing vents the client has shown the user
+type MarkSeenRequest struct {
+	VentIDs []string `json:"vent_ids" binding:"required,max=100"`
+}
+
+// MarkSeen - POST /feed/seen
+// Vents marked seen are left out of later home feeds.
+func MarkSeen(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req MarkSeenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids, ok := objectIDs(c, req.VentIDs, "invalid vent id")
+	if !ok {
+		return
+	}
+	if err := services.MarkSeen(context.Background(), userID, ids); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark vents seen"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetAcademicRequest - payload naming where the user studies; either id may
+// be omitted, and a department fills in its university
+type SetAcademicRequest struct {
+	UniversityID *string `json:"university_id,omitempty"`
+	DepartmentID *string `json:"department_id,omitempty"`
+}
+
+// SetAcademic - PUT /me/academic
+func SetAcademic(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req SetAcademicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	universityID, err := optionalObjectID(req.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid university_id"})
+		return
+	}
+	departmentID, err := optionalObjectID(req.DepartmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid department_id"})
+		return
+	}
+	scope, err := services.SetAcademicHome(context.Background(), userID, universityID, departmentID)
+	if err != nil {
+		respondServiceError(c, err, "failed to update academic details")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"university_id": scope.UniversityID, "department_id": scope.DepartmentID})
+}
+
+// SetCoursesRequest - payload listing the courses the user is enrolled in
+type SetCoursesRequest struct {
+	CourseIDs []string `json:"course_ids" binding:"max=30"`
+}
+
+// SetCourses - PUT /me/courses
+func SetCourses(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req SetCoursesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids, ok := objectIDs(c, req.CourseIDs, "invalid course id")
+	if !ok {
+		return
+	}
+	if err := services.SetEnrolledCourses(context.Background(), userID, ids); err != nil {
+		respondServiceError(c, err, "failed to update courses")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"course_ids": ids})
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateFilterRuleRequest - payload when adding a content filter rule. Terms
+// are used by keyword rules, Pattern by regex rules and Limit by the length
+// and link limits. Keyword terms match whole words in any script; list
+// transliterations of a word as separate terms.
+type CreateFilterRuleRequest struct {
+	Name    string   `json:"name" binding:"required,max=100"`
+	Kind    string   `json:"kind" binding:"required,oneof=keyword regex phone email max_length max_links"`
+	Action  string   `json:"action" binding:"required,oneof=reject hold mask"`
+	Terms   []string `json:"terms"`
+	Pattern string   `json:"pattern"`
+	Limit   int      `json:"limit"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// GetFilterRules - GET /admin/filters
+func GetFilterRules(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	rules, err := services.FilterRules(context.Background(), actorID)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch filter rules")
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateFilterRule - POST /admin/filters
+// New rules are enabled unless enabled is false.
+func CreateFilterRule(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var req CreateFilterRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.FilterRule{
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Action:  req.Action,
+		Terms:   req.Terms,
+		Pattern: req.Pattern,
+		Limit:   req.Limit,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if err := services.CreateFilterRule(context.Background(), actorID, rule); err != nil {
+		respondServiceError(c, err, "failed to create filter rule")
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateFilterRuleRequest - payload when editing a filter rule; omitted
+// fields are unchanged. A rule's kind cannot be changed.
+type UpdateFilterRuleRequest struct {
+	Name    *string   `json:"name" binding:"omitempty,max=100"`
+	Action  *string   `json:"action" binding:"omitempty,oneof=reject hold mask"`
+	Terms   *[]string `json:"terms"`
+	Pattern *string   `json:"pattern"`
+	Limit   *int      `json:"limit"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// UpdateFilterRule - PATCH /admin/filters/:id
+func UpdateFilterRule(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	ruleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req UpdateFilterRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.UpdateFilterRule(context.Background(), actorID, ruleID, services.FilterRulePatch{
+		Name:    req.Name,
+		Action:  req.Action,
+		Terms:   req.Terms,
+		Pattern: req.Pattern,
+		Limit:   req.Limit,
+		Enabled: req.Enabled,
+	})
+	if err != nil {
+		respondServiceError(c, err, "failed to update filter rule")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteFilterRule - DELETE /admin/filters/:id
+func DeleteFilterRule(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	ruleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := services.DeleteFilterRule(context.Background(), actorID, ruleID); err != nil {
+		respondServiceError(c, err, "failed to delete filter rule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": ruleID})
+}
+
+// TestFilterRequest - payload when trying content against the filter rules
+type TestFilterRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// TestFilter - POST /admin/filters/test
+// Runs content through the active rules without posting it.
+func TestFilter(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var req TestFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := services.TestFilter(context.Background(), actorID, req.Content)
+	if err != nil {
+		respondServiceError(c, err, "failed to test content")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
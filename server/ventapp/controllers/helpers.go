@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errInvalidLimit = errors.New("invalid limit")
+
+// optionalObjectID converts an optional hex id from a request body.
+func optionalObjectID(hex *string) (*primitive.ObjectID, error) {
+	if hex == nil || *hex == "" {
+		return nil, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(*hex)
+	if err != nil {
+		return nil, err
+	}
+	return &oid, nil
+}
+
+// queryObjectID reads an optional hex id from the query string.
+func queryObjectID(c *gin.Context, key string) (*primitive.ObjectID, error) {
+	raw := c.Query(key)
+	return optionalObjectID(&raw)
+}
+
+// objectIDs parses hex ids, responding with msg if any is invalid.
+func objectIDs(c *gin.Context, hexes []string, msg string) ([]primitive.ObjectID, bool) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	for _, h := range hexes {
+		id, err := primitive.ObjectIDFromHex(h)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// pageParams reads the cursor and limit query parameters shared by list
+// endpoints. Limits above the maximum are clamped by the repository.
+func pageParams(c *gin.Context) (*repositories.Cursor, int64, error) {
+	var after *repositories.Cursor
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := repositories.DecodeCursor(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+		after = cur
+	}
+
+	var limit int64
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			return nil, 0, errInvalidLimit
+		}
+		limit = n
+	}
+	return after, limit, nil
+}
+
+// screened renders a vent or reply as returned to its author after posting or
+// editing it. When screening acted on it a "screening" field gives the action
+// and reasons, and a "support" field carries any crisis support offered.
+func screened(doc interface{}, s *services.Screening) interface{} {
+	if s == nil || (s.Action == "" && s.Support == nil) {
+		return doc
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return doc
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return doc
+	}
+	if s.Action != "" {
+		out["screening"] = s
+	}
+	if s.Support != nil {
+		out["support"] = s.Support
+	}
+	return out
+}
+
+// respondServiceError maps the errors services return to HTTP responses.
+// fallback is the message used for unexpected errors.
+func respondServiceError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case errors.Is(err, services.ErrEditWindowClosed),
+		errors.Is(err, services.ErrBanned),
+		errors.Is(err, services.ErrSuspended),
+		errors.Is(err, services.ErrBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidParent),
+		errors.Is(err, services.ErrNotQuestion),
+		errors.Is(err, services.ErrInvalidAnswer),
+		errors.Is(err, services.ErrInvalidSanction),
+		errors.Is(err, services.ErrNotAppealable),
+		errors.Is(err, services.ErrInvalidFilterRule),
+		errors.Is(err, services.ErrInvalidQuery),
+		errors.Is(err, services.ErrBannedTag),
+		errors.Is(err, services.ErrInvalidTag),
+		errors.Is(err, services.ErrInvalidScope),
+		errors.Is(err, services.ErrSelfBlock),
+		errors.Is(err, services.ErrInvalidPrefs),
+		errors.Is(err, repositories.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrContentRejected):
+		// an author in crisis is offered help even when the post is refused
+		body := gin.H{"error": err.Error()}
+		var rejected *services.RejectedError
+		if errors.As(err, &rejected) && rejected.Support != nil {
+			body["support"] = rejected.Support
+			if userID, ok := middleware.CurrentUserID(c); ok {
+				publishTo([]primitive.ObjectID{userID}, websocket.MessageTypeCrisisSupport, rejected.Support)
+			}
+		}
+		c.JSON(http.StatusUnprocessableEntity, body)
+	case errors.Is(err, services.ErrRateLimited):
+		var limited *services.RateLimitError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrConflict),
+		errors.Is(err, services.ErrAlreadyReported),
+		errors.Is(err, services.ErrAlreadyAppealed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetModerationLog - GET /admin/moderation?action=&target_type=&target_id=&actor_id=&subject_id=&cursor=&limit=
+func GetModerationLog(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f := repositories.ModerationFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+	if f.TargetID, err = queryObjectID(c, "target_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_id"})
+		return
+	}
+	if f.ActorID, err = queryObjectID(c, "actor_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id"})
+		return
+	}
+	if f.SubjectID, err = queryObjectID(c, "subject_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subject_id"})
+		return
+	}
+
+	page, err := services.ModerationLog(context.Background(), userID, f, after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch moderation log"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetMyModeration - GET /me/moderation?cursor=&limit=
+// Lists the moderation actions taken against the caller, so they can appeal them.
+func GetMyModeration(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := services.ModerationHistory(context.Background(), userID, after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch moderation history"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// FileAppealRequest - payload when appealing a moderation action
+type FileAppealRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+// FileAppeal - POST /moderation/:id/appeal
+func FileAppeal(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	actionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req FileAppealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appeal, err := services.FileAppeal(context.Background(), userID, actionID, req.Message)
+	if err != nil {
+		respondServiceError(c, err, "failed to file appeal")
+		return
+	}
+	c.JSON(http.StatusCreated, appeal)
+}
+
+// GetAppeals - GET /admin/appeals?status=&cursor=&limit=
+// status defaults to pending. Only appeals the caller may decide are listed.
+func GetAppeals(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.DefaultQuery("status", models.AppealPending)
+	switch status {
+	case models.AppealPending, models.AppealAccepted, models.AppealRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+
+	page, err := services.AppealQueue(context.Background(), userID, status, after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch appeals"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// AcceptAppeal - POST /admin/appeals/:id/accept
+// Accepting reverses the appealed action.
+func AcceptAppeal(c *gin.Context) {
+	decideAppeal(c, true)
+}
+
+// RejectAppeal - POST /admin/appeals/:id/reject
+func RejectAppeal(c *gin.Context) {
+	decideAppeal(c, false)
+}
+
+func decideAppeal(c *gin.Context, accept bool) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	appealID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// the note is optional, so a missing body is fine
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	appeal, err := services.DecideAppeal(context.Background(), userID, appealID, accept, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "failed to decide appeal")
+		return
+	}
+	c.JSON(http.StatusOK, appeal)
+}
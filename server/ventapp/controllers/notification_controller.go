@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetNotifications - GET /notifications?unread=&cursor=&limit=
+// unread=true lists only unread notifications. The response carries the
+// caller's unread count alongside the page.
+func GetNotifications(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := services.Notifications(context.Background(), userID, c.Query("unread") == "true", after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUnreadNotifications - GET /notifications/unread-count
+func GetUnreadNotifications(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	unread, err := services.UnreadNotifications(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkNotificationRead - POST /notifications/:id/read
+func MarkNotificationRead(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	unread, err := services.MarkNotificationRead(context.Background(), userID, id)
+	if err != nil {
+		respondServiceError(c, err, "failed to mark notification read")
+		return
+	}
+	publishTo([]primitive.ObjectID{userID}, websocket.MessageTypeNotificationsRead, gin.H{"unread_count": unread})
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkAllNotificationsRead - POST /notifications/read-all
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	unread, err := services.MarkAllNotificationsRead(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark notifications read"})
+		return
+	}
+	publishTo([]primitive.ObjectID{userID}, websocket.MessageTypeNotificationsRead, gin.H{"unread_count": unread})
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// GetNotificationSettings - GET /me/notification-settings
+func GetNotificationSettings(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	prefs, err := services.NotificationSettings(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// NotificationSettingsRequest - payload when changing notification settings
+type NotificationSettingsRequest struct {
+	Types      map[string]models.ChannelPrefs `json:"types"`
+	QuietHours *models.QuietHours             `json:"quiet_hours"`
+	Timezone   string                         `json:"timezone"`
+	Digest     string                         `json:"digest"`
+}
+
+// UpdateNotificationSettings - PUT /me/notification-settings
+// Replaces the caller's settings: types left out go back to their default
+// channels, and a missing quiet_hours turns quiet hours off.
+func UpdateNotificationSettings(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req NotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefs, err := services.UpdateNotificationSettings(context.Background(), userID, models.NotificationPrefs{
+		Types:      req.Types,
+		QuietHours: req.QuietHours,
+		Timezone:   req.Timezone,
+		Digest:     req.Digest,
+	})
+	if err != nil {
+		respondServiceError(c, err, "failed to update notification settings")
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
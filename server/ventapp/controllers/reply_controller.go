@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateReplyRequest - payload when replying to a vent or to another reply
+type CreateReplyRequest struct {
+	Content  string  `json:"content" binding:"required,min=1"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+// CreateReply - POST /posts/:id/replies
+func CreateReply(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req CreateReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	parentID, err := optionalObjectID(req.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+		return
+	}
+
+	rep, s, err := services.CreateReply(context.Background(), userID, ventID, parentID, req.Content)
+	if err != nil {
+		respondServiceError(c, err, "failed to create reply")
+		return
+	}
+
+	// a shadowed or held reply must look posted to its author and to nobody else
+	if rep.ShadowedUntil == nil && !rep.UnderReview {
+		publishReply(websocket.MessageTypeReply, rep)
+	}
+	notifyUnderReview(s.Review)
+	offerSupport(userID, s)
+	c.JSON(http.StatusCreated, screened(rep, s))
+}
+
+// GetReplies - GET /posts/:id/replies?parent_id=&depth=&cursor=&limit=
+// Without parent_id it pages through top-level replies; with it, it loads
+// more of one branch.
+func GetReplies(c *gin.Context) {
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	parentID, err := queryObjectID(c, "parent_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+		return
+	}
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	depth := 0
+	if raw := c.Query("depth"); raw != "" {
+		if depth, err = strconv.Atoi(raw); err != nil || depth < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depth"})
+			return
+		}
+	}
+
+	vent, err := ventRepo.FindByID(context.Background(), ventID)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch vent")
+		return
+	}
+	if !ventVisible(c, vent) {
+		return
+	}
+
+	var viewerID *primitive.ObjectID
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		viewerID = &userID
+	}
+
+	tree, err := services.ReplyTree(context.Background(), viewerID, ventID, parentID, after, limit, services.ReplyDepth(depth))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch replies"})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// UpdateReplyRequest - payload when editing a reply
+type UpdateReplyRequest struct {
+	Content string `json:"content" binding:"required,min=1"`
+}
+
+// UpdateReply - PATCH /replies/:id
+func UpdateReply(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	replyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req UpdateReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rep, s, err := services.EditReply(context.Background(), userID, replyID, req.Content)
+	if err != nil {
+		respondServiceError(c, err, "failed to update reply")
+		return
+	}
+
+	if !rep.UnderReview && rep.VisibleTo(nil) {
+		publishReply(websocket.MessageTypeReplyUpdated, rep)
+	}
+	notifyUnderReview(s.Review)
+	offerSupport(userID, s)
+	c.JSON(http.StatusOK, screened(rep, s))
+}
+
+// DeleteReply - DELETE /replies/:id
+func DeleteReply(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	replyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// reason is optional, so a missing body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	rep, err := services.DeleteReply(context.Background(), userID, replyID, req.Reason)
+	if err != nil {
+		respondServiceError(c, err, "failed to delete reply")
+		return
+	}
+
+	publishReply(websocket.MessageTypeReplyDeleted, rep)
+	c.JSON(http.StatusOK, rep)
+}
+
+// VoteReply - PUT /replies/:id/vote
+func VoteReply(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	replyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	value, ok := services.ParseVote(req.Vote)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vote must be up, down or none"})
+		return
+	}
+
+	if rep, err := replyRepo.FindByID(context.Background(), replyID); err != nil {
+		respondServiceError(c, err, "failed to fetch reply")
+		return
+	} else if rep.IsDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reply not found"})
+		return
+	}
+
+	rep, err := services.VoteReply(context.Background(), userID, replyID, value)
+	if err != nil {
+		respondServiceError(c, err, "failed to record vote")
+		return
+	}
+
+	tally := gin.H{
+		"target_type": models.TargetReply,
+		"target_id":   rep.ID,
+		"vent_id":     rep.VentID,
+		"upvotes":     rep.Upvotes,
+		"downvotes":   rep.Downvotes,
+		"score":       rep.Score,
+	}
+	publishFrom(rep.AuthorID, replyVent(rep), websocket.MessageTypeVote, tally)
+
+	tally["my_vote"] = req.Vote
+	c.JSON(http.StatusOK, tally)
+}
+
+// AcceptAnswerRequest - payload when accepting an answer to a question
+type AcceptAnswerRequest struct {
+	ReplyID string `json:"reply_id" binding:"required"`
+}
+
+// AcceptAnswer - POST /posts/:id/accept
+func AcceptAnswer(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req AcceptAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	replyID, err := primitive.ObjectIDFromHex(req.ReplyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reply_id"})
+		return
+	}
+
+	rep, err := services.AcceptAnswer(context.Background(), userID, ventID, replyID)
+	if err != nil {
+		respondServiceError(c, err, "failed to accept answer")
+		return
+	}
+
+	publishFrom(rep.AuthorID, replyVent(rep), websocket.MessageTypeAnswerAccepted, gin.H{"vent_id": ventID, "reply_id": rep.ID})
+	c.JSON(http.StatusOK, rep)
+}
+
+// ClearAcceptedAnswer - DELETE /posts/:id/accept
+func ClearAcceptedAnswer(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	vent, err := services.ClearAcceptedAnswer(context.Background(), userID, ventID)
+	if err != nil {
+		respondServiceError(c, err, "failed to clear accepted answer")
+		return
+	}
+
+	publishFrom(vent.AuthorID, vent, websocket.MessageTypeAnswerAccepted, gin.H{"vent_id": ventID, "reply_id": nil})
+	c.JSON(http.StatusOK, gin.H{"vent_id": ventID, "reply_id": nil})
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateReportRequest - payload when reporting a vent or reply
+type CreateReportRequest struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+// ReportVent - POST /posts/:id/report
+func ReportVent(c *gin.Context) {
+	fileReport(c, models.TargetVent)
+}
+
+// ReportReply - POST /replies/:id/report
+func ReportReply(c *gin.Context) {
+	fileReport(c, models.TargetReply)
+}
+
+func fileReport(c *gin.Context, targetType string) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.ValidReportReason(req.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be one of harassment, self-harm, spam, doxxing, other"})
+		return
+	}
+
+	rep, hidden, err := services.FileReport(context.Background(), userID, targetType, targetID, req.Reason, req.Details)
+	if err != nil {
+		respondServiceError(c, err, "failed to file report")
+		return
+	}
+	notifyUnderReview(hidden)
+	c.JSON(http.StatusCreated, rep)
+}
+
+// offerSupport privately sends crisis support to an author whose post
+// screening flagged. It does nothing when s is nil or offers no support.
+func offerSupport(authorID primitive.ObjectID, s *services.Screening) {
+	if s != nil && s.Support != nil {
+		publishTo([]primitive.ObjectID{authorID}, websocket.MessageTypeCrisisSupport, s.Support)
+	}
+}
+
+// notifyUnderReview tells the author and moderators that content was put
+// under review automatically. It does nothing when hidden is nil.
+func notifyUnderReview(hidden *services.AutoHidden) {
+	if hidden != nil {
+		publishTo(append(hidden.Moderators, hidden.AuthorID), websocket.MessageTypeContentUnderReview, hidden)
+	}
+}
+
+// GetReportQueue - GET /admin/reports?status=&reason=&target_type=&priority=&cursor=&limit=
+// Reports are grouped by target, priority groups first, then most reported.
+// status defaults to open.
+// priority=true selects the priority queue of content that may signal a crisis.
+func GetReportQueue(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := repositories.ReportFilter{
+		Status:     c.DefaultQuery("status", models.ReportOpen),
+		Reason:     c.Query("reason"),
+		TargetType: c.Query("target_type"),
+		Priority:   c.Query("priority") == "true",
+	}
+	switch filter.Status {
+	case models.ReportOpen, models.ReportResolved, models.ReportDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	if filter.Reason != "" && filter.Reason != models.ReportFiltered && !models.ValidReportReason(filter.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reason"})
+		return
+	}
+	if _, ok := reportTargetType(filter.TargetType); filter.TargetType != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_type"})
+		return
+	}
+
+	page, err := services.ReportQueue(context.Background(), userID, filter, after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetTargetReports - GET /admin/reports/:target_type/:target_id
+func GetTargetReports(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetType, targetID, ok := reportTargetParams(c)
+	if !ok {
+		return
+	}
+
+	reports, err := services.TargetReports(context.Background(), userID, targetType, targetID)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch reports")
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReportsRequest - payload when resolving the reports on a target
+type ResolveReportsRequest struct {
+	RemoveContent bool   `json:"remove_content"`
+	Note          string `json:"note" binding:"max=1000"`
+}
+
+// ResolveReports - POST /admin/reports/:target_type/:target_id/resolve
+func ResolveReports(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetType, targetID, ok := reportTargetParams(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closed, err := services.ResolveReports(context.Background(), userID, targetType, targetID, req.RemoveContent, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "failed to resolve reports")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"closed": closed})
+}
+
+// RestoreContent - POST /admin/reports/:target_type/:target_id/restore
+// Lifts the review hold on auto-hidden content without closing its reports.
+func RestoreContent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetType, targetID, ok := reportTargetParams(c)
+	if !ok {
+		return
+	}
+
+	// the note is optional, so a missing body is fine
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	restored, err := services.RestoreContent(context.Background(), userID, targetType, targetID, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "failed to restore content")
+		return
+	}
+
+	if restored != nil {
+		publishTo(append(restored.Moderators, restored.AuthorID), websocket.MessageTypeContentRestored, restored)
+	}
+	c.JSON(http.StatusOK, gin.H{"restored": targetID})
+}
+
+// DismissReportsRequest - payload when dismissing the reports on a target
+type DismissReportsRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// DismissReports - POST /admin/reports/:target_type/:target_id/dismiss
+func DismissReports(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	targetType, targetID, ok := reportTargetParams(c)
+	if !ok {
+		return
+	}
+
+	// the note is optional, so a missing body is fine
+	var req DismissReportsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	closed, err := services.DismissReports(context.Background(), userID, targetType, targetID, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "failed to dismiss reports")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"closed": closed})
+}
+
+func reportTargetType(s string) (string, bool) {
+	switch s {
+	case models.TargetVent, models.TargetReply:
+		return s, true
+	}
+	return "", false
+}
+
+// reportTargetParams reads :target_type and :target_id, responding with 400
+// when either is invalid.
+func reportTargetParams(c *gin.Context) (string, primitive.ObjectID, bool) {
+	targetType, ok := reportTargetType(c.Param("target_type"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_type"})
+		return "", primitive.NilObjectID, false
+	}
+	targetID, err := primitive.ObjectIDFromHex(c.Param("target_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_id"})
+		return "", primitive.NilObjectID, false
+	}
+	return targetType, targetID, true
+}
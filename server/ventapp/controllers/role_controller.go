@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var roleRepo = repositories.NewRoleRepository()
+
+// GrantRoleRequest - payload when granting a role to a user
+type GrantRoleRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	Role      string `json:"role" binding:"required"`
+	ScopeType string `json:"scope_type" binding:"required"`
+	ScopeID   string `json:"scope_id"`
+	Reason    string `json:"reason"`
+}
+
+// GrantRole - POST /admin/roles
+func GrantRole(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var req GrantRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userOID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+	role := models.Role(req.Role)
+	if !role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+		return
+	}
+	scopeType := models.ScopeType(req.ScopeType)
+	if !scopeType.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope_type"})
+		return
+	}
+
+	binding := &models.RoleBinding{
+		UserID:    userOID,
+		Role:      role,
+		ScopeType: scopeType,
+		GrantedBy: actorID,
+	}
+	if scopeType != models.ScopeGlobal {
+		scopeOID, err := primitive.ObjectIDFromHex(req.ScopeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scope_id is required for scoped roles"})
+			return
+		}
+		binding.ScopeID = &scopeOID
+	}
+
+	// the actor must be able to manage roles at the level they are granting
+	scope, err := services.BindingScope(context.Background(), *binding)
+	if err != nil {
+		respondServiceError(c, err, "failed to check permissions")
+		return
+	}
+	allowed, err := services.Can(context.Background(), actorID, models.PermManageRoles, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	if _, err := userRepo.FindByID(context.Background(), userOID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := roleRepo.Create(context.Background(), binding); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "user already holds this role in this scope"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant role"})
+		return
+	}
+
+	audit := &models.RoleAuditEntry{
+		Action:    models.RoleAuditGrant,
+		BindingID: binding.ID,
+		UserID:    binding.UserID,
+		Role:      binding.Role,
+		ScopeType: binding.ScopeType,
+		ScopeID:   binding.ScopeID,
+		ActorID:   actorID,
+		Reason:    req.Reason,
+	}
+	if err := roleRepo.CreateAudit(context.Background(), audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "role granted but audit entry failed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, binding)
+}
+
+// RevokeRole - DELETE /admin/roles/:id
+func RevokeRole(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// reason is optional, so a missing body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	binding, err := roleRepo.FindByID(context.Background(), id)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role binding not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch role binding"})
+		return
+	}
+
+	scope, err := services.BindingScope(context.Background(), *binding)
+	if err != nil {
+		respondServiceError(c, err, "failed to check permissions")
+		return
+	}
+	allowed, err := services.Can(context.Background(), actorID, models.PermManageRoles, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	if err := roleRepo.Delete(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke role"})
+		return
+	}
+
+	audit := &models.RoleAuditEntry{
+		Action:    models.RoleAuditRevoke,
+		BindingID: binding.ID,
+		UserID:    binding.UserID,
+		Role:      binding.Role,
+		ScopeType: binding.ScopeType,
+		ScopeID:   binding.ScopeID,
+		ActorID:   actorID,
+		Reason:    req.Reason,
+	}
+	if err := roleRepo.CreateAudit(context.Background(), audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "role revoked but audit entry failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": binding.ID})
+}
+
+// GetUserRoles - GET /admin/users/:id/roles
+// Lists only the bindings in scopes the caller manages roles in.
+func GetUserRoles(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	userOID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	scopes, err := services.ScopesWith(context.Background(), actorID, models.PermManageRoles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+		return
+	}
+	bindings, err := roleRepo.FindByUserIn(context.Background(), userOID, scopes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch roles"})
+		return
+	}
+	c.JSON(http.StatusOK, bindings)
+}
+
+// GetRoleAudit - GET /admin/roles/audit?user_id=
+// Lists only the entries in scopes the caller can view the audit trail of.
+func GetRoleAudit(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var userFilter *primitive.ObjectID
+	if raw := c.Query("user_id"); raw != "" {
+		oid, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		userFilter = &oid
+	}
+
+	scopes, err := services.ScopesWith(context.Background(), actorID, models.PermViewAudit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+		return
+	}
+	entries, err := roleRepo.FindAudit(context.Background(), userFilter, scopes, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit trail"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IssueSanctionRequest - payload when suspending, banning or shadow-banning a
+// user. Omitting expires_at makes a ban or shadow-ban permanent.
+type IssueSanctionRequest struct {
+	Kind      string     `json:"kind" binding:"required,oneof=suspension ban shadow_ban"`
+	Reason    string     `json:"reason" binding:"required,max=1000"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IssueSanction - POST /admin/users/:id/sanctions
+func IssueSanction(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req IssueSanctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s, err := services.IssueSanction(context.Background(), actorID, userID, req.Kind, req.Reason, req.ExpiresAt)
+	if err != nil {
+		respondServiceError(c, err, "failed to issue sanction")
+		return
+	}
+
+	if s.Kind == models.SanctionBan {
+		disconnect(userID)
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// GetUserSanctions - GET /admin/users/:id/sanctions
+func GetUserSanctions(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	sanctions, err := services.UserSanctions(context.Background(), actorID, userID)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch sanctions")
+		return
+	}
+	c.JSON(http.StatusOK, sanctions)
+}
+
+// LiftSanction - DELETE /admin/sanctions/:id
+func LiftSanction(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// reason is optional, so a missing body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	s, err := services.LiftSanction(context.Background(), actorID, id, req.Reason)
+	if err != nil {
+		respondServiceError(c, err, "failed to lift sanction")
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SaveVentRequest - optional payload when saving a vent
+type SaveVentRequest struct {
+	Folder string `json:"folder" binding:"max=50"`
+}
+
+// SaveVent - POST /posts/:id/save
+func SaveVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// the folder is optional, so a missing body is fine
+	var req SaveVentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	folder := strings.TrimSpace(req.Folder)
+
+	if err := services.SaveVent(context.Background(), userID, ventID, folder); err != nil {
+		respondServiceError(c, err, "failed to save vent")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"vent_id": ventID, "saved": true, "folder": folder})
+}
+
+// UnsaveVent - DELETE /posts/:id/save
+func UnsaveVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := services.UnsaveVent(context.Background(), userID, ventID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unsave vent"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"vent_id": ventID, "saved": false})
+}
+
+// GetSavedVents - GET /me/saved?folder=&cursor=&limit=
+func GetSavedVents(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var folder *string
+	if f, ok := c.GetQuery("folder"); ok {
+		f = strings.TrimSpace(f)
+		folder = &f
+	}
+
+	page, err := services.SavedVents(context.Background(), userID, folder, after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch saved vents"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetSaveFolders - GET /me/saved/folders
+func GetSaveFolders(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	folders, err := services.SaveFolders(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch folders"})
+		return
+	}
+	c.JSON(http.StatusOK, folders)
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Search - GET /search?q=&type=&tag=&university_id=&department_id=&course_id=&kind=&from=&to=&cursor=&limit=
+// type is vent or reply; both are searched when it is empty. from and to take
+// RFC 3339 times or plain dates, a plain to date including the whole day.
+// Content the caller blocked, muted or hid is left out.
+func Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	targetType := c.Query("type")
+	if targetType != "" && targetType != models.TargetVent && targetType != models.TargetReply {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type"})
+		return
+	}
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := repositories.SearchFilter{Query: q, Tag: strings.TrimSpace(c.Query("tag"))}
+	if filter.UniversityID, err = queryObjectID(c, "university_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid university_id"})
+		return
+	}
+	if filter.DepartmentID, err = queryObjectID(c, "department_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid department_id"})
+		return
+	}
+	if filter.CourseID, err = queryObjectID(c, "course_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid course_id"})
+		return
+	}
+	switch kind := c.Query("kind"); kind {
+	case "", models.KindVent, models.KindQuestion:
+		filter.Kind = kind
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind"})
+		return
+	}
+	if filter.From, err = queryTime(c, "from", false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.To, err = queryTime(c, "to", true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var viewerID *primitive.ObjectID
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		viewerID = &userID
+	}
+
+	page, err := services.Search(context.Background(), viewerID, filter, targetType, after, limit)
+	if err != nil {
+		respondServiceError(c, err, "failed to search")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// queryTime reads an optional RFC 3339 time or plain date from the query.
+// With endOfDay, a plain date means the end of that day.
+func queryTime(c *gin.Context, key string, endOfDay bool) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
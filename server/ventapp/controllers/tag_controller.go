@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTags - GET /tags?q=&status=&cursor=&limit=
+// q autocompletes tag names by prefix. status=banned or status=merged lists
+// those tags instead, for admins.
+func GetTags(c *gin.Context) {
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	f := repositories.TagFilter{Prefix: c.Query("q")}
+	switch status := repositories.TagStatus(c.Query("status")); status {
+	case repositories.TagsActive, repositories.TagsBanned, repositories.TagsMerged:
+		f.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	var viewerID *primitive.ObjectID
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		viewerID = &userID
+	}
+
+	page, err := services.Tags(context.Background(), viewerID, f, after, limit)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch tags")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetTag - GET /tags/:name
+func GetTag(c *gin.Context) {
+	tag, err := services.Tag(context.Background(), c.Param("name"))
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// FollowTag - PUT /tags/:name/follow
+func FollowTag(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	tag, err := services.FollowTag(context.Background(), userID, c.Param("name"))
+	if err != nil {
+		respondServiceError(c, err, "failed to follow tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// UnfollowTag - DELETE /tags/:name/follow
+func UnfollowTag(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	if err := services.UnfollowTag(context.Background(), userID, c.Param("name")); err != nil {
+		respondServiceError(c, err, "failed to unfollow tag")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetFollowedTags - GET /me/tags
+func GetFollowedTags(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	follows, err := services.FollowedTags(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch followed tags"})
+		return
+	}
+	c.JSON(http.StatusOK, follows)
+}
+
+// UpdateTagRequest - payload when describing a tag
+type UpdateTagRequest struct {
+	Description string `json:"description" binding:"max=500"`
+}
+
+// UpdateTag - PATCH /admin/tags/:name
+func UpdateTag(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var req UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tag, err := services.DescribeTag(context.Background(), actorID, c.Param("name"), req.Description)
+	if err != nil {
+		respondServiceError(c, err, "failed to update tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// BanTag - POST /admin/tags/:name/ban
+func BanTag(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	tag, err := services.BanTag(context.Background(), actorID, c.Param("name"))
+	if err != nil {
+		respondServiceError(c, err, "failed to ban tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// UnbanTag - DELETE /admin/tags/:name/ban
+func UnbanTag(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	tag, err := services.UnbanTag(context.Background(), actorID, c.Param("name"))
+	if err != nil {
+		respondServiceError(c, err, "failed to unban tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTagRequest - payload when merging a tag into another
+type MergeTagRequest struct {
+	Into string `json:"into" binding:"required"`
+}
+
+// MergeTag - POST /admin/tags/:name/merge
+// Returns the tag merged into.
+func MergeTag(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+
+	var req MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tag, err := services.MergeTag(context.Background(), actorID, c.Param("name"), req.Into)
+	if err != nil {
+		respondServiceError(c, err, "failed to merge tag")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
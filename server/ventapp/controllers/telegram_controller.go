@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"ventapp/server/ventapp/services"
+	"ventapp/server/ventapp/telegram"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TelegramWebhook - POST /telegram/webhook
+// Receives updates for the bot from Telegram, which sends the webhook's
+// secret token in the X-Telegram-Bot-Api-Secret-Token header. Updates that
+// fail are answered with an error so Telegram delivers them again.
+func TelegramWebhook(c *gin.Context) {
+	if !services.ValidTelegramWebhook(c.GetHeader("X-Telegram-Bot-Api-Secret-Token")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid secret token"})
+		return
+	}
+
+	var update telegram.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.HandleTelegramUpdate(context.Background(), update); err != nil {
+		log.Printf("telegram update %d failed: %v", update.UpdateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to handle update"})
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -2,23 +2,37 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
 	"net/http"
+	"slices"
 	"time"
 
+	"ventapp/server/ventapp/middleware"
 	"ventapp/server/ventapp/models"
 	"ventapp/server/ventapp/repositories"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var ventRepo = repositories.NewVentRepository()
+var (
+	ventRepo  = repositories.NewVentRepository()
+	replyRepo = repositories.NewReplyRepository()
+)
 
-// CreateVentRequest - payload when creating a vent
+// CreateVentRequest - payload when creating a vent. The author is always the
+// authenticated user; an author_id in the body is ignored.
 type CreateVentRequest struct {
-	AuthorID string   `json:"author_id" binding:"required"`
-	Content  string   `json:"content" binding:"required,min=1"`
-	Tags     []string `json:"tags"`
+	Content   string   `json:"content" binding:"required,min=1"`
+	Tags      []string `json:"tags"`
+	Anonymous bool     `json:"anonymous"`
+	Kind      string   `json:"kind" binding:"omitempty,oneof=vent question"`
 	// Optional related IDs passed as hex string; convert on server if present
 	CourseID     *string `json:"course_id,omitempty"`
 	UniversityID *string `json:"university_id,omitempty"`
@@ -32,43 +46,366 @@ func CreateVent(c *gin.Context) {
 		return
 	}
 
-	// Convert author id to ObjectID
-	authorOID, err := primitive.ObjectIDFromHex(req.AuthorID)
+	authorOID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	universityOID, err := optionalObjectID(req.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid university_id"})
+		return
+	}
+	departmentOID, err := optionalObjectID(req.DepartmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid department_id"})
+		return
+	}
+	courseOID, err := optionalObjectID(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid course_id"})
 		return
 	}
 
-	// Note: optional related IDs (course/university/department) are accepted in the
-	// request but not yet stored in the Vent model. If you add corresponding fields
-	// to models.Vent, convert them with primitive.ObjectIDFromHex and set here.
+	scope, err := services.ResolveScope(context.Background(), universityOID, departmentOID, courseOID)
+	if errors.Is(err, services.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate vent scope"})
+		return
+	}
 
-	vent := &models.Vent{
-		AuthorID:  authorOID,
-		Content:   req.Content,
-		Tags:      req.Tags,
-		Upvotes:   0,
-		Downvotes: 0,
-		Views:     0,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		IsDeleted: false,
+	kind := req.Kind
+	if kind == "" {
+		kind = models.KindVent
 	}
 
-	if err := ventRepo.Create(context.Background(), vent); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create vent"})
+	vent := &models.Vent{
+		AuthorID:     authorOID,
+		Anonymous:    req.Anonymous,
+		Kind:         kind,
+		Content:      req.Content,
+		Tags:         req.Tags,
+		UniversityID: scope.UniversityID,
+		DepartmentID: scope.DepartmentID,
+		CourseID:     scope.CourseID,
+		Upvotes:      0,
+		Downvotes:    0,
+		Views:        0,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		IsDeleted:    false,
+	}
+	s, err := services.CreateVent(context.Background(), vent)
+	if err != nil {
+		respondServiceError(c, err, "failed to create vent")
 		return
 	}
 
-	c.JSON(http.StatusCreated, vent)
+	notifyUnderReview(s.Review)
+	offerSupport(authorOID, s)
+	c.JSON(http.StatusCreated, screened(vent, s))
 }
 
+// GetVents - GET /posts?university_id=&department_id=&course_id=&kind=&tag=&followed=&unanswered=&sort=&cursor=&limit=
+// department_id=mine selects the caller's own department; followed=true keeps
+// vents carrying a tag the caller follows. Vents the caller blocked, muted or
+// hid are left out.
 func GetVents(c *gin.Context) {
-	// Very simple: return last N vents
-	cursor, err := repositories.NewVentRepository().FindRecent(context.Background(), 50)
+	sort, ok := repositories.ParseVentSort(c.Query("sort"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort"})
+		return
+	}
+	after, limit, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filter repositories.VentFilter
+	if filter.UniversityID, err = queryObjectID(c, "university_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid university_id"})
+		return
+	}
+	if c.Query("department_id") == "mine" {
+		userID, ok := middleware.CurrentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		u, err := userRepo.FindByID(context.Background(), userID)
+		if err != nil {
+			respondServiceError(c, err, "failed to fetch user")
+			return
+		}
+		if u.DepartmentID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no department set on your profile"})
+			return
+		}
+		filter.DepartmentID = u.DepartmentID
+	} else if filter.DepartmentID, err = queryObjectID(c, "department_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid department_id"})
+		return
+	}
+	if filter.CourseID, err = queryObjectID(c, "course_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid course_id"})
+		return
+	}
+
+	switch kind := c.Query("kind"); kind {
+	case "", models.KindVent, models.KindQuestion:
+		filter.Kind = kind
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind"})
+		return
+	}
+	filter.Unanswered = c.Query("unanswered") == "true"
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		filter.ViewerID = &userID
+	}
+	if filter.Exclude, err = services.ViewerExclusions(context.Background(), filter.ViewerID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vents"})
+		return
+	}
+	if raw := c.Query("tag"); raw != "" {
+		tag, err := services.NormaliseTag(context.Background(), raw)
+		if err != nil {
+			respondServiceError(c, err, "failed to fetch vents")
+			return
+		}
+		filter.Tags = []string{tag}
+	}
+	if c.Query("followed") == "true" {
+		if filter.ViewerID == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		followed, err := services.FollowedTagNames(context.Background(), *filter.ViewerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch followed tags"})
+			return
+		}
+		if filter.Tags == nil {
+			filter.Tags = followed
+		} else if !slices.Contains(followed, filter.Tags[0]) {
+			// a tag the caller does not follow
+			filter.Tags = []string{}
+		}
+	}
+
+	page, err := ventRepo.FindPage(context.Background(), filter, sort, after, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vents"})
 		return
 	}
-	c.JSON(http.StatusOK, cursor)
+	c.JSON(http.StatusOK, page)
+}
+
+// GetVent - GET /posts/:id
+func GetVent(c *gin.Context) {
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	vent, err := ventRepo.FindByID(context.Background(), ventID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vent not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vent"})
+		return
+	}
+	if !ventVisible(c, vent) {
+		return
+	}
+
+	author := models.AnonymousAuthor
+	if !vent.Anonymous {
+		if u, err := userRepo.FindByID(context.Background(), vent.AuthorID); err == nil {
+			author = u.Profile()
+		}
+	}
+
+	myVote := "none"
+	saved := false
+	viewer := viewerFingerprint(c)
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		viewer = userID.Hex()
+		v, err := services.UserVote(context.Background(), userID, models.TargetVent, ventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vote"})
+			return
+		}
+		switch v {
+		case 1:
+			myVote = "up"
+		case -1:
+			myVote = "down"
+		}
+		if saved, err = services.IsSaved(context.Background(), userID, ventID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch saved state"})
+			return
+		}
+		if err := services.MarkSeen(context.Background(), userID, []primitive.ObjectID{ventID}); err != nil {
+			log.Printf("seen record failed for vent %s: %v", ventID.Hex(), err)
+		}
+	}
+
+	if services.Views.Record(ventID, viewer) {
+		vent.Views++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vent":        vent,
+		"author":      author,
+		"my_vote":     myVote,
+		"saved":       saved,
+		"reply_count": vent.ReplyCount,
+	})
+}
+
+// UpdateVentRequest - payload when editing a vent; omitted fields are unchanged
+type UpdateVentRequest struct {
+	Content *string   `json:"content" binding:"omitempty,min=1"`
+	Tags    *[]string `json:"tags"`
+}
+
+// UpdateVent - PATCH /posts/:id
+func UpdateVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req UpdateVentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Content == nil && req.Tags == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
+	}
+
+	vent, s, err := services.EditVent(context.Background(), userID, ventID, req.Content, req.Tags)
+	if err != nil {
+		respondServiceError(c, err, "failed to update vent")
+		return
+	}
+
+	if !vent.UnderReview && vent.VisibleTo(nil) {
+		publishFrom(vent.AuthorID, vent, websocket.MessageTypeVentUpdated, vent)
+	}
+	notifyUnderReview(s.Review)
+	offerSupport(userID, s)
+	c.JSON(http.StatusOK, screened(vent, s))
+}
+
+// DeleteVent - DELETE /posts/:id
+func DeleteVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// reason is optional, so a missing body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	vent, err := services.DeleteVent(context.Background(), userID, ventID, req.Reason)
+	if err != nil {
+		respondServiceError(c, err, "failed to delete vent")
+		return
+	}
+
+	publishFrom(vent.AuthorID, vent, websocket.MessageTypeVentDeleted, gin.H{"id": vent.ID})
+	c.JSON(http.StatusOK, gin.H{"deleted": vent.ID})
+}
+
+// GetVentRevisions - GET /admin/posts/:id/revisions
+func GetVentRevisions(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	revisions, err := services.VentRevisions(context.Background(), userID, ventID)
+	if err != nil {
+		respondServiceError(c, err, "failed to fetch revisions")
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RestoreVent - POST /admin/posts/:id/restore
+func RestoreVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// reason is optional, so a missing body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	vent, err := services.RestoreVent(context.Background(), userID, ventID, req.Reason)
+	if err != nil {
+		respondServiceError(c, err, "failed to restore vent")
+		return
+	}
+
+	publishFrom(vent.AuthorID, vent, websocket.MessageTypeVentRestored, vent)
+	c.JSON(http.StatusOK, vent)
+}
+
+// ventVisible reports whether the caller may see the vent. Vents under review
+// or by shadow-banned authors are hidden from everyone but their author and
+// moderators; for them it responds with 404 itself.
+func ventVisible(c *gin.Context, vent *models.Vent) bool {
+	var viewerID *primitive.ObjectID
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		viewerID = &userID
+	}
+	if !vent.UnderReview && vent.VisibleTo(viewerID) {
+		return true
+	}
+	ok, err := services.CanSeeHidden(context.Background(), viewerID, vent.AuthorID, vent.Scope())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vent not found"})
+		return false
+	}
+	return true
+}
+
+// viewerFingerprint identifies an anonymous viewer well enough to de-duplicate
+// their views without storing their address.
+func viewerFingerprint(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:12])
 }
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VoteRequest - payload when voting; vote is one of up, down or none
+type VoteRequest struct {
+	Vote string `json:"vote" binding:"required"`
+}
+
+// VoteVent - PUT /posts/:id/vote
+func VoteVent(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	ventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	value, ok := services.ParseVote(req.Vote)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vote must be up, down or none"})
+		return
+	}
+
+	if _, err := ventRepo.FindByID(context.Background(), ventID); err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vent not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vent"})
+		return
+	}
+
+	vent, err := services.VoteVent(context.Background(), userID, ventID, value)
+	if err != nil {
+		respondServiceError(c, err, "failed to record vote")
+		return
+	}
+
+	tally := gin.H{
+		"target_type": models.TargetVent,
+		"target_id":   vent.ID,
+		"upvotes":     vent.Upvotes,
+		"downvotes":   vent.Downvotes,
+		"score":       vent.Score,
+	}
+	publishFrom(vent.AuthorID, vent, websocket.MessageTypeVote, tally)
+
+	tally["my_vote"] = req.Vote
+	c.JSON(http.StatusOK, tally)
+}
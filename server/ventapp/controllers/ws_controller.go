@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/middleware"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
+	"ventapp/server/websocket"
+
+	"github.com/gin-gonic/gin"
+	gorilla "github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var hub *websocket.Hub
+
+// SetHub makes controllers publish realtime events through h, which skips
+// users who blocked or muted the sender of an event, and pushes new
+// notifications through it.
+func SetHub(h *websocket.Hub) {
+	hub = h
+	hub.SetAvoiders(func(userID string) []string {
+		id, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil
+		}
+		return avoiders(id, nil)
+	})
+	services.OnNotification(func(n models.Notification, unread int64) {
+		publishTo([]primitive.ObjectID{n.UserID}, websocket.MessageTypeNotification, gin.H{"notification": n, "unread_count": unread})
+	})
+}
+
+var upgrader = gorilla.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the client is served from a different origin during development
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// publishFrom broadcasts an event about content by authorID, optionally on
+// a vent, to every connected client except users who excluded the author or
+// the vent. It is a no-op when no hub is configured.
+func publishFrom(authorID primitive.ObjectID, vent *models.Vent, msgType string, data interface{}) {
+	if hub == nil {
+		return
+	}
+	hub.BroadcastExcept(avoiders(authorID, vent), websocket.Message{Type: msgType, Data: data, Timestamp: time.Now()})
+}
+
+// publishReply broadcasts an event about a reply like publishFrom, looking up
+// the reply's vent.
+func publishReply(msgType string, rep *models.Reply) {
+	publishFrom(rep.AuthorID, replyVent(rep), msgType, rep)
+}
+
+// replyVent returns the vent a reply belongs to, for publishFrom. Lookup
+// failures are logged and return nil, which filters by the author alone.
+func replyVent(rep *models.Reply) *models.Vent {
+	vent, err := ventRepo.FindByID(context.Background(), rep.VentID)
+	if err != nil {
+		log.Printf("vent lookup failed for reply %s: %v", rep.ID.Hex(), err)
+		return nil
+	}
+	return vent
+}
+
+// avoiders returns the hex ids of the users who should not receive events
+// about content by authorID. Lookup failures are logged and skip no one.
+func avoiders(authorID primitive.ObjectID, vent *models.Vent) []string {
+	ids, err := services.Avoiders(context.Background(), authorID, vent)
+	if err != nil {
+		log.Printf("avoider lookup failed for user %s: %v", authorID.Hex(), err)
+		return nil
+	}
+	hexes := make([]string, len(ids))
+	for i, id := range ids {
+		hexes[i] = id.Hex()
+	}
+	return hexes
+}
+
+// publishTo sends an event to every connection of the given users. It is a
+// no-op when no hub is configured.
+func publishTo(userIDs []primitive.ObjectID, msgType string, data interface{}) {
+	if hub == nil {
+		return
+	}
+	msg := websocket.Message{Type: msgType, Data: data, Timestamp: time.Now()}
+	for _, id := range userIDs {
+		hub.BroadcastToUser(id.Hex(), msg)
+	}
+}
+
+// disconnect closes every realtime connection the user has open. It is a
+// no-op when no hub is configured.
+func disconnect(userID primitive.ObjectID) {
+	if hub == nil {
+		return
+	}
+	hub.DisconnectUser(userID.Hex())
+}
+
+// ServeWS - GET /ws?token=
+// Browsers cannot set headers on WebSocket requests, so the JWT comes in the query string.
+func ServeWS(c *gin.Context) {
+	claims, err := config.ParseToken(c.Query("token"))
+	if err != nil || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	sub, _ := claims["sub"].(string)
+	userOID, err := primitive.ObjectIDFromHex(sub)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	user, err := userRepo.FindByID(context.Background(), userOID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unknown user"})
+		return
+	}
+	st, err := services.UserStanding(context.Background(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check account standing"})
+		return
+	}
+	if st.Ban != nil {
+		c.JSON(http.StatusForbidden, middleware.BannedResponse(st.Ban))
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+
+	client := websocket.NewClient(hub, conn, user.ID.Hex(), user.DisplayName)
+	hub.Register(client)
+	go client.WritePump()
+	go client.ReadPump()
+}
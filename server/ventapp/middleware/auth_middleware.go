@@ -1,15 +1,27 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/services"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const ContextUserIDKey = "user_id"
 
+// banExempt lists the routes a banned user can still reach, so that they can
+// see and appeal their ban, and stop being emailed meanwhile.
+var banExempt = map[string]bool{
+	"/me/moderation":            true,
+	"/moderation/:id/appeal":    true,
+	"/me/notification-settings": true,
+}
+
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
@@ -37,6 +49,82 @@ func JWTAuth() gin.HandlerFunc {
 		if sub, ok := claims["sub"].(string); ok {
 			c.Set(ContextUserIDKey, sub)
 		}
+
+		// a banned account's tokens stop working until the ban ends
+		if userID, ok := CurrentUserID(c); ok {
+			st, err := services.UserStanding(context.Background(), userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check account standing"})
+				return
+			}
+			if st.Ban != nil && !banExempt[c.FullPath()] {
+				c.AbortWithStatusJSON(http.StatusForbidden, BannedResponse(st.Ban))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// BannedResponse is the body sent to a banned user.
+func BannedResponse(ban *models.Sanction) gin.H {
+	return gin.H{"error": "account banned", "reason": ban.Reason, "expires_at": ban.ExpiresAt}
+}
+
+// CurrentUserID returns the authenticated user's id as set by JWTAuth.
+func CurrentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	sub := c.GetString(ContextUserIDKey)
+	if sub == "" {
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(sub)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// RequireAuth rejects anonymous requests. It must run after JWTAuth.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentUserID(c); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests from users that hold perm in no scope at all.
+// Handlers behind it are expected to check the resource scope with services.Can.
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return RequireAnyPermission(perm)
+}
+
+// RequireAnyPermission is RequirePermission for routes that serve holders of
+// any one of several permissions.
+func RequireAnyPermission(perms ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		allowed := false
+		for _, perm := range perms {
+			var err error
+			if allowed, err = services.CanAnywhere(context.Background(), userID, perm); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+				return
+			}
+			if allowed {
+				break
+			}
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
 		c.Next()
 	}
 }
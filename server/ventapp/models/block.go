@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of relation a user can have to another user.
+const (
+	// RelationBlock hides the target's content from the user and stops the
+	// target replying to the user or sending them events.
+	RelationBlock = "block"
+	// RelationMute only hides the target's content from the user.
+	RelationMute = "mute"
+)
+
+// Relation records that a user blocked or muted another user. A user has at
+// most one relation to each other user; a block supersedes a mute.
+type Relation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	TargetID  primitive.ObjectID `bson:"target_id" json:"target_id"`
+	Kind      string             `bson:"kind" json:"kind"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// MutedTag records that a user does not want to see vents carrying a tag.
+type MutedTag struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	Tag       string             `bson:"tag" json:"tag"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// HiddenVent records that a user hid a single vent.
+type HiddenVent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	VentID    primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
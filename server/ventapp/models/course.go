@@ -0,0 +1,10 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type Course struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DepartmentID primitive.ObjectID `bson:"department_id" json:"department_id"`
+	Title        string             `bson:"title" json:"title"`
+	Code         string             `bson:"code" json:"code"`
+}
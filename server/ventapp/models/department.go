@@ -0,0 +1,9 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type Department struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UniversityID primitive.ObjectID `bson:"university_id" json:"university_id"`
+	Name         string             `bson:"name" json:"name"`
+}
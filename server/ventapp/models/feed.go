@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeedSession is one ranking of a user's home feed. Pages are read from it in
+// order, so scrolling shows each vent once even as scores change.
+type FeedSession struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID   `bson:"user_id" json:"user_id"`
+	VentIDs   []primitive.ObjectID `bson:"vent_ids" json:"vent_ids"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// SeenVent records that a user has seen a vent, keeping it out of their home
+// feed for a while.
+type SeenVent struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	VentID primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	SeenAt time.Time          `bson:"seen_at" json:"seen_at"`
+}
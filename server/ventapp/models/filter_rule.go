@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Filter rule kinds
+const (
+	// RuleKeyword matches any of Terms as whole words, in any script.
+	RuleKeyword = "keyword"
+	// RuleRegex matches Pattern.
+	RuleRegex = "regex"
+	// RulePhone matches phone numbers.
+	RulePhone = "phone"
+	// RuleEmail matches email addresses.
+	RuleEmail = "email"
+	// RuleMaxLength matches content longer than Limit characters.
+	RuleMaxLength = "max_length"
+	// RuleMaxLinks matches content with more than Limit links.
+	RuleMaxLinks = "max_links"
+)
+
+// Filter actions, in increasing order of severity
+const (
+	FilterMask   = "mask"
+	FilterHold   = "hold"
+	FilterReject = "reject"
+)
+
+// FilterRule is one admin-editable rule of the content filter run before
+// vents and replies are saved. Mask is only meaningful for rules that match
+// spans of text, i.e. not for the length and link limits.
+type FilterRule struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Action    string             `bson:"action" json:"action"`
+	Terms     []string           `bson:"terms,omitempty" json:"terms,omitempty"`
+	Pattern   string             `bson:"pattern,omitempty" json:"pattern,omitempty"`
+	Limit     int                `bson:"limit,omitempty" json:"limit,omitempty"`
+	Enabled   bool               `bson:"enabled" json:"enabled"`
+	UpdatedBy primitive.ObjectID `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Masks reports whether the rule's kind matches spans of text that can be masked.
+func (r FilterRule) Masks() bool {
+	switch r.Kind {
+	case RuleKeyword, RuleRegex, RulePhone, RuleEmail:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Moderation actions
+const (
+	ModActionAutoHide       = "auto_hide"
+	ModActionHold           = "hold"
+	ModActionRestore        = "restore"
+	ModActionDelete         = "delete"
+	ModActionUndelete       = "undelete"
+	ModActionResolveReports = "resolve_reports"
+	ModActionDismissReports = "dismiss_reports"
+	ModActionSuspend        = "suspend"
+	ModActionBan            = "ban"
+	ModActionShadowBan      = "shadow_ban"
+	ModActionLiftSanction   = "lift_sanction"
+)
+
+// Appealable reports whether the affected user may appeal the action.
+func Appealable(action string) bool {
+	switch action {
+	case ModActionAutoHide, ModActionHold, ModActionDelete, ModActionSuspend, ModActionBan, ModActionShadowBan:
+		return true
+	}
+	return false
+}
+
+// ModerationAction is one entry in the append-only moderation log. ActorID
+// is nil when the system acted on its own, e.g. when auto-hiding reported
+// content or holding content the content filter flagged. SubjectID is the user the action affects: the content's author or
+// the sanctioned user. Before and After snapshot the target around the
+// action, and the scope ids place it in the academic hierarchy so that
+// moderators only see their own scope.
+type ModerationAction struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Action       string              `bson:"action" json:"action"`
+	ActorID      *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	SubjectID    primitive.ObjectID  `bson:"subject_id" json:"subject_id"`
+	TargetType   string              `bson:"target_type" json:"target_type"`
+	TargetID     primitive.ObjectID  `bson:"target_id" json:"target_id"`
+	SanctionID   *primitive.ObjectID `bson:"sanction_id,omitempty" json:"sanction_id,omitempty"`
+	Reason       string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Before       bson.M              `bson:"before,omitempty" json:"before,omitempty"`
+	After        bson.M              `bson:"after,omitempty" json:"after,omitempty"`
+	UniversityID *primitive.ObjectID `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	DepartmentID *primitive.ObjectID `bson:"department_id,omitempty" json:"department_id,omitempty"`
+	CourseID     *primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// Appeal statuses
+const (
+	AppealPending  = "pending"
+	AppealAccepted = "accepted"
+	AppealRejected = "rejected"
+)
+
+// Appeal is a user's request to reverse a moderation action against them.
+// Each action can be appealed once. The target type and scope ids are copied
+// from the action, so each moderator is shown the appeals they may decide.
+type Appeal struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ActionID     primitive.ObjectID  `bson:"action_id" json:"action_id"`
+	TargetType   string              `bson:"target_type" json:"target_type"`
+	UserID       primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Message      string              `bson:"message" json:"message"`
+	Status       string              `bson:"status" json:"status"`
+	UniversityID *primitive.ObjectID `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	DepartmentID *primitive.ObjectID `bson:"department_id,omitempty" json:"department_id,omitempty"`
+	CourseID     *primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	DecidedBy    *primitive.ObjectID `bson:"decided_by,omitempty" json:"decided_by,omitempty"`
+	DecidedAt    *time.Time          `bson:"decided_at,omitempty" json:"decided_at,omitempty"`
+	Decision     string              `bson:"decision,omitempty" json:"decision,omitempty"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotifyReply          = "reply"
+	NotifyMention        = "mention"
+	NotifyVote           = "vote"
+	NotifyAnswerAccepted = "answer_accepted"
+	NotifyModeration     = "moderation"
+)
+
+// Grouped reports whether notifications of a type on the same target are
+// gathered into one unread notification rather than sent one by one.
+func Grouped(notificationType string) bool {
+	return notificationType == NotifyReply || notificationType == NotifyVote
+}
+
+// Notification tells a user about activity on their content or account.
+// Target is the vent or reply the activity concerns, or the user for account
+// moderation; VentID is the vent to link to, if any. Grouped notifications
+// count the distinct users behind them in ActorCount, and CreatedAt is when
+// their latest event arrived. Action is the moderation action taken, with
+// ActionID pointing at its log entry for appeals.
+type Notification struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID   `bson:"user_id" json:"-"`
+	Type       string               `bson:"type" json:"type"`
+	TargetType string               `bson:"target_type" json:"target_type"`
+	TargetID   primitive.ObjectID   `bson:"target_id" json:"target_id"`
+	VentID     *primitive.ObjectID  `bson:"vent_id,omitempty" json:"vent_id,omitempty"`
+	Action     string               `bson:"action,omitempty" json:"action,omitempty"`
+	ActionID   *primitive.ObjectID  `bson:"action_id,omitempty" json:"action_id,omitempty"`
+	Grouped    bool                 `bson:"grouped" json:"-"`
+	ActorIDs   []primitive.ObjectID `bson:"actor_ids,omitempty" json:"-"`
+	ActorCount int                  `bson:"actor_count" json:"actor_count"`
+	Read       bool                 `bson:"read" json:"read"`
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// Message is the notification as a sentence for the user. Actors are never
+// named, since votes are private and vents may be anonymous.
+func (n Notification) Message() string {
+	switch n.Type {
+	case NotifyReply:
+		if n.ActorCount > 1 {
+			return fmt.Sprintf("%d people replied to your %s", n.ActorCount, n.TargetType)
+		}
+		return fmt.Sprintf("Someone replied to your %s", n.TargetType)
+	case NotifyMention:
+		return fmt.Sprintf("Someone mentioned you in a %s", n.TargetType)
+	case NotifyVote:
+		if n.ActorCount > 1 {
+			return fmt.Sprintf("%d people upvoted your %s", n.ActorCount, n.TargetType)
+		}
+		return fmt.Sprintf("Someone upvoted your %s", n.TargetType)
+	case NotifyAnswerAccepted:
+		return "Your answer was accepted"
+	case NotifyModeration:
+		switch n.Action {
+		case ModActionAutoHide:
+			return fmt.Sprintf("Your %s was hidden for review after being reported", n.TargetType)
+		case ModActionHold:
+			return fmt.Sprintf("Your %s is being held for review", n.TargetType)
+		case ModActionRestore:
+			return fmt.Sprintf("Your %s was reviewed and is visible again", n.TargetType)
+		case ModActionDelete:
+			return fmt.Sprintf("Your %s was removed by a moderator", n.TargetType)
+		case ModActionUndelete:
+			return fmt.Sprintf("Your %s was restored by a moderator", n.TargetType)
+		case ModActionSuspend:
+			return "Your account was suspended"
+		case ModActionBan:
+			return "Your account was banned"
+		case ModActionLiftSanction:
+			return "A restriction on your account was lifted"
+		}
+	}
+	return "You have a new notification"
+}
+
+// MarshalJSON adds the notification's message.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	type notification Notification
+	return json.Marshal(struct {
+		notification
+		Message string `json:"message"`
+	}{notification: notification(n), Message: n.Message()})
+}
+
+// NotificationPage is a page of a user's notifications with how many of all
+// their notifications are unread.
+type NotificationPage struct {
+	Page[Notification]
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// HeldNotification is a notification kept back from the channels outside
+// the app during its user's quiet hours, to be sent at ReleaseAt.
+type HeldNotification struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	Notification Notification       `bson:"notification"`
+	ReleaseAt    time.Time          `bson:"release_at"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationTypes lists every type of notification, in the order settings
+// show them.
+var NotificationTypes = []string{NotifyReply, NotifyMention, NotifyVote, NotifyAnswerAccepted, NotifyModeration}
+
+// Notification channels
+const (
+	ChannelInApp    = "in_app"
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+)
+
+// Digest frequencies
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// ChannelPrefs says which channels one type of notification is delivered on.
+type ChannelPrefs struct {
+	InApp    bool `bson:"in_app" json:"in_app"`
+	Email    bool `bson:"email" json:"email"`
+	Telegram bool `bson:"telegram" json:"telegram"`
+}
+
+// Enabled reports whether the channel is switched on.
+func (c ChannelPrefs) Enabled(channel string) bool {
+	switch channel {
+	case ChannelInApp:
+		return c.InApp
+	case ChannelEmail:
+		return c.Email
+	case ChannelTelegram:
+		return c.Telegram
+	}
+	return false
+}
+
+// DefaultChannels are the channels a type of notification uses until the
+// user chooses otherwise: everything in the app, and only what needs
+// attention outside it.
+func DefaultChannels(notificationType string) ChannelPrefs {
+	switch notificationType {
+	case NotifyVote:
+		return ChannelPrefs{InApp: true}
+	case NotifyModeration:
+		return ChannelPrefs{InApp: true, Email: true, Telegram: true}
+	}
+	return ChannelPrefs{InApp: true, Telegram: true}
+}
+
+// QuietHours is a daily span in which nothing is sent outside the app;
+// notifications are held until it ends.
+// Start and End are "15:04" times in the user's time zone; a span that ends
+// earlier than it starts runs past midnight.
+type QuietHours struct {
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+}
+
+// Contains reports whether the local time t falls in the quiet hours.
+// Malformed times never match.
+func (q QuietHours) Contains(t time.Time) bool {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return from <= now && now < to
+	}
+	return now >= from || now < to
+}
+
+// Until returns when the quiet hours containing the local time t end.
+// Malformed times end at t.
+func (q QuietHours) Until(t time.Time) time.Time {
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return t
+	}
+	until := time.Date(t.Year(), t.Month(), t.Day(), end.Hour(), end.Minute(), 0, 0, t.Location())
+	if !until.After(t) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// NotificationPrefs are a user's notification settings. Types missing from
+// Types use DefaultChannels, and an empty Timezone the server's default.
+// LastDigestAt is when the user was last sent a digest.
+type NotificationPrefs struct {
+	UserID       primitive.ObjectID      `bson:"_id" json:"-"`
+	Types        map[string]ChannelPrefs `bson:"types" json:"types"`
+	QuietHours   *QuietHours             `bson:"quiet_hours,omitempty" json:"quiet_hours"`
+	Timezone     string                  `bson:"timezone,omitempty" json:"timezone"`
+	Digest       string                  `bson:"digest" json:"digest"`
+	LastDigestAt *time.Time              `bson:"last_digest_at,omitempty" json:"last_digest_at,omitempty"`
+	UpdatedAt    time.Time               `bson:"updated_at" json:"updated_at"`
+}
+
+// Channels returns the channels a type of notification is delivered on.
+func (p NotificationPrefs) Channels(notificationType string) ChannelPrefs {
+	if c, ok := p.Types[notificationType]; ok {
+		return c
+	}
+	return DefaultChannels(notificationType)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHours(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	overnight := QuietHours{Start: "22:00", End: "07:00"}
+	daytime := QuietHours{Start: "09:00", End: "17:30"}
+	tests := []struct {
+		name  string
+		q     QuietHours
+		now   string
+		quiet bool
+		until string
+	}{
+		{"before overnight span", overnight, "2026-10-19 21:59", false, ""},
+		{"overnight span starts", overnight, "2026-10-19 22:00", true, "2026-10-20 07:00"},
+		{"overnight before midnight", overnight, "2026-10-19 23:30", true, "2026-10-20 07:00"},
+		{"overnight at midnight", overnight, "2026-10-20 00:00", true, "2026-10-20 07:00"},
+		{"overnight after midnight", overnight, "2026-10-20 06:59", true, "2026-10-20 07:00"},
+		{"overnight span ends", overnight, "2026-10-20 07:00", false, ""},
+		{"daytime span", daytime, "2026-10-19 12:00", true, "2026-10-19 17:30"},
+		{"after daytime span", daytime, "2026-10-19 17:30", false, ""},
+		{"month boundary", overnight, "2026-10-31 23:00", true, "2026-11-01 07:00"},
+		{"malformed", QuietHours{Start: "late", End: "07:00"}, "2026-10-19 23:00", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := at(tt.now)
+			if got := tt.q.Contains(now); got != tt.quiet {
+				t.Fatalf("Contains(%s) = %v, want %v", tt.now, got, tt.quiet)
+			}
+			if !tt.quiet {
+				return
+			}
+			if got := tt.q.Until(now); !got.Equal(at(tt.until)) {
+				t.Errorf("Until(%s) = %s, want %s", tt.now, got.Format("2006-01-02 15:04"), tt.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// Page is the envelope returned by paginated list endpoints. NextCursor is
+// opaque to clients and only set when HasMore is true.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
@@ -1,20 +1,67 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Reply struct {
-	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	VentID    primitive.ObjectID  `bson:"vent_id" json:"vent_id"`
-	AuthorID  primitive.ObjectID  `bson:"author_id" json:"author_id"`
-	Content   string              `bson:"content" json:"content"`
-	ParentID  *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
-	Upvotes   int                 `bson:"upvotes" json:"upvotes"`
-	Downvotes int                 `bson:"downvotes" json:"downvotes"`
-	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time           `bson:"updated_at" json:"updated_at"`
-	IsDeleted bool                `bson:"is_deleted" json:"is_deleted"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	VentID        primitive.ObjectID  `bson:"vent_id" json:"vent_id"`
+	AuthorID      primitive.ObjectID  `bson:"author_id" json:"author_id"`
+	Content       string              `bson:"content" json:"content"`
+	ParentID      *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Depth         int                 `bson:"depth" json:"depth"`
+	ReplyCount    int                 `bson:"reply_count" json:"reply_count"`
+	Accepted      bool                `bson:"accepted" json:"accepted"`
+	Upvotes       int                 `bson:"upvotes" json:"upvotes"`
+	Downvotes     int                 `bson:"downvotes" json:"downvotes"`
+	Score         int                 `bson:"score" json:"score"`
+	ReportCount   int                 `bson:"report_count" json:"-"`
+	ReportWeight  float64             `bson:"report_weight" json:"-"`
+	UnderReview   bool                `bson:"under_review" json:"under_review"`
+	ShadowedUntil *time.Time          `bson:"shadowed_until,omitempty" json:"-"`
+	Simhash       int64               `bson:"simhash,omitempty" json:"-"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+	IsDeleted     bool                `bson:"is_deleted" json:"is_deleted"`
+	DeletedBy     *primitive.ObjectID `bson:"deleted_by,omitempty" json:"-"`
+	DeletedAt     *time.Time          `bson:"deleted_at,omitempty" json:"-"`
+	DeleteReason  string              `bson:"delete_reason,omitempty" json:"-"`
+}
+
+// MarshalJSON renders deleted replies as tombstones: their place in the
+// thread and their children survive, but content and author do not.
+func (r Reply) MarshalJSON() ([]byte, error) {
+	type reply Reply
+	if !r.IsDeleted {
+		return json.Marshal(reply(r))
+	}
+	r.Content = ""
+	return json.Marshal(struct {
+		reply
+		AuthorID *primitive.ObjectID `json:"author_id,omitempty"`
+	}{reply: reply(r)})
+}
+
+// VisibleTo reports whether a reply by a shadow-banned author may be shown
+// to the viewer, which is only the author themselves while the ban lasts.
+// viewerID is nil for anonymous viewers.
+func (r Reply) VisibleTo(viewerID *primitive.ObjectID) bool {
+	if r.ShadowedUntil == nil || !r.ShadowedUntil.After(time.Now()) {
+		return true
+	}
+	return viewerID != nil && *viewerID == r.AuthorID
+}
+
+// ReplyNode is a reply with one page of its children. NextCursor loads the
+// rest of the branch; children beyond the requested depth are not loaded and
+// can be fetched using the reply as the parent.
+type ReplyNode struct {
+	Reply      Reply       `json:"reply"`
+	Children   []ReplyNode `json:"children"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
 }
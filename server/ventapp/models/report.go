@@ -6,11 +6,78 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Report reasons
+const (
+	ReportHarassment = "harassment"
+	ReportSelfHarm   = "self-harm"
+	ReportSpam       = "spam"
+	ReportDoxxing    = "doxxing"
+	ReportOther      = "other"
+)
+
+// ReportFiltered is the reason on reports the system files for content the
+// content filter held for review. Users cannot report with it.
+const ReportFiltered = "filtered"
+
+// ValidReportReason reports whether reason is one of the report reasons.
+func ValidReportReason(reason string) bool {
+	switch reason {
+	case ReportHarassment, ReportSelfHarm, ReportSpam, ReportDoxxing, ReportOther:
+		return true
+	}
+	return false
+}
+
+// Report statuses
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Report outcomes recorded when a moderator closes reports
+const (
+	OutcomeContentRemoved = "content_removed"
+	OutcomeNoAction       = "no_action"
+	OutcomeDismissed      = "dismissed"
+)
+
+// Report is one user's report of a vent or reply. VentID is the vent the
+// target belongs to (the target itself for vents), and the scope ids are
+// copied from it so moderators only see reports in their scope. Weight is what
+// the report counts towards auto-hiding the target; it is zero unless the
+// reporter is trusted. Priority reports, filed by the system for content that
+// may signal a crisis, lead the moderation queue.
 type Report struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	VentID    primitive.ObjectID `bson:"vent_id" json:"vent_id"`
-	Reporter  primitive.ObjectID `bson:"reporter" json:"reporter"`
-	Reason    string             `bson:"reason" json:"reason"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	Resolved  bool               `bson:"resolved" json:"resolved"`
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	TargetType   string              `bson:"target_type" json:"target_type"`
+	TargetID     primitive.ObjectID  `bson:"target_id" json:"target_id"`
+	VentID       primitive.ObjectID  `bson:"vent_id" json:"vent_id"`
+	Reporter     primitive.ObjectID  `bson:"reporter" json:"reporter"`
+	Reason       string              `bson:"reason" json:"reason"`
+	Details      string              `bson:"details,omitempty" json:"details,omitempty"`
+	Weight       float64             `bson:"weight" json:"weight"`
+	Priority     bool                `bson:"priority,omitempty" json:"priority,omitempty"`
+	UniversityID *primitive.ObjectID `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	DepartmentID *primitive.ObjectID `bson:"department_id,omitempty" json:"department_id,omitempty"`
+	CourseID     *primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	Status       string              `bson:"status" json:"status"`
+	Resolved     bool                `bson:"resolved" json:"resolved"`
+	ResolvedBy   *primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt   *time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	Outcome      string              `bson:"outcome,omitempty" json:"outcome,omitempty"`
+	Note         string              `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// ReportGroup is the moderation queue's view of every report on one target.
+type ReportGroup struct {
+	TargetID        primitive.ObjectID `bson:"_id" json:"target_id"`
+	TargetType      string             `bson:"target_type" json:"target_type"`
+	VentID          primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	Count           int                `bson:"count" json:"count"`
+	Reasons         []string           `bson:"reasons" json:"reasons"`
+	Priority        bool               `bson:"priority" json:"priority"`
+	FirstReportedAt time.Time          `bson:"first_reported_at" json:"first_reported_at"`
+	LastReportedAt  time.Time          `bson:"created_at" json:"last_reported_at"`
 }
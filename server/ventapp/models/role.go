@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permission names a single capability that a role can grant.
+type Permission string
+
+const (
+	PermModerateContent Permission = "content.moderate"
+	PermPinPost         Permission = "content.pin"
+	PermViewReports     Permission = "reports.view"
+	PermResolveReports  Permission = "reports.resolve"
+	PermManageUsers     Permission = "users.manage"
+	PermManageRoles     Permission = "roles.manage"
+	PermViewAudit       Permission = "audit.view"
+)
+
+// Role is a named bundle of permissions.
+type Role string
+
+const (
+	RoleAdmin         Role = "admin"
+	RoleModerator     Role = "moderator"
+	RoleDepartmentRep Role = "department_rep"
+	RoleAuditor       Role = "auditor"
+)
+
+// RolePermissions lists what each role grants within the scope it is bound to.
+var RolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermModerateContent, PermPinPost, PermViewReports, PermResolveReports,
+		PermManageUsers, PermManageRoles, PermViewAudit,
+	},
+	RoleModerator:     {PermModerateContent, PermViewReports, PermResolveReports, PermViewAudit},
+	RoleDepartmentRep: {PermPinPost},
+	RoleAuditor:       {PermViewReports, PermViewAudit},
+}
+
+// Grants reports whether the role includes the given permission.
+func (r Role) Grants(p Permission) bool {
+	for _, perm := range RolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether the role is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := RolePermissions[r]
+	return ok
+}
+
+// ScopeType is the level of the academic hierarchy a role binding applies to.
+type ScopeType string
+
+const (
+	ScopeGlobal     ScopeType = "global"
+	ScopeUniversity ScopeType = "university"
+	ScopeDepartment ScopeType = "department"
+	ScopeCourse     ScopeType = "course"
+)
+
+// Valid reports whether the scope type is one of the known scope types.
+func (s ScopeType) Valid() bool {
+	switch s {
+	case ScopeGlobal, ScopeUniversity, ScopeDepartment, ScopeCourse:
+		return true
+	}
+	return false
+}
+
+// RoleBinding grants a role to a user within a scope. ScopeID is nil for global bindings.
+type RoleBinding struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Role      Role                `bson:"role" json:"role"`
+	ScopeType ScopeType           `bson:"scope_type" json:"scope_type"`
+	ScopeID   *primitive.ObjectID `bson:"scope_id" json:"scope_id,omitempty"`
+	GrantedBy primitive.ObjectID  `bson:"granted_by" json:"granted_by"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// ResourceScope describes where a piece of content lives in the academic hierarchy.
+// A zero ResourceScope only matches global bindings.
+type ResourceScope struct {
+	UniversityID *primitive.ObjectID
+	DepartmentID *primitive.ObjectID
+	CourseID     *primitive.ObjectID
+}
+
+// Covers reports whether the binding applies to content in the given scope.
+// The scope must have its parents filled in, as services.ResolveScope does,
+// for bindings to cover what lies below their university or department.
+func (b RoleBinding) Covers(s ResourceScope) bool {
+	var target *primitive.ObjectID
+	switch b.ScopeType {
+	case ScopeGlobal:
+		return true
+	case ScopeUniversity:
+		target = s.UniversityID
+	case ScopeDepartment:
+		target = s.DepartmentID
+	case ScopeCourse:
+		target = s.CourseID
+	}
+	return target != nil && b.ScopeID != nil && *target == *b.ScopeID
+}
+
+// ResourceScope returns the scope a binding applies to, for checking whether an
+// actor may manage bindings at that level.
+func (b RoleBinding) ResourceScope() ResourceScope {
+	switch b.ScopeType {
+	case ScopeUniversity:
+		return ResourceScope{UniversityID: b.ScopeID}
+	case ScopeDepartment:
+		return ResourceScope{DepartmentID: b.ScopeID}
+	case ScopeCourse:
+		return ResourceScope{CourseID: b.ScopeID}
+	}
+	return ResourceScope{}
+}
+
+const (
+	RoleAuditGrant  = "grant"
+	RoleAuditRevoke = "revoke"
+)
+
+// RoleAuditEntry records a grant or revoke of a role binding.
+type RoleAuditEntry struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Action    string              `bson:"action" json:"action"`
+	BindingID primitive.ObjectID  `bson:"binding_id" json:"binding_id"`
+	UserID    primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Role      Role                `bson:"role" json:"role"`
+	ScopeType ScopeType           `bson:"scope_type" json:"scope_type"`
+	ScopeID   *primitive.ObjectID `bson:"scope_id" json:"scope_id,omitempty"`
+	ActorID   primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	Reason    string              `bson:"reason" json:"reason"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sanction kinds. A suspension stops a user from posting for a while; a ban
+// locks them out of their account; a shadow-ban leaves them posting, but what
+// they post is visible only to themselves.
+const (
+	SanctionSuspension = "suspension"
+	SanctionBan        = "ban"
+	SanctionShadowBan  = "shadow_ban"
+)
+
+// ValidSanctionKind reports whether kind is one of the sanction kinds.
+func ValidSanctionKind(kind string) bool {
+	switch kind {
+	case SanctionSuspension, SanctionBan, SanctionShadowBan:
+		return true
+	}
+	return false
+}
+
+// Sanction is a moderation measure against a user account. ExpiresAt is nil
+// for permanent sanctions. Lifted sanctions are kept with who lifted them and why.
+type Sanction struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Kind       string              `bson:"kind" json:"kind"`
+	Reason     string              `bson:"reason" json:"reason"`
+	IssuedBy   primitive.ObjectID  `bson:"issued_by" json:"issued_by"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	ExpiresAt  *time.Time          `bson:"expires_at" json:"expires_at"`
+	LiftedBy   *primitive.ObjectID `bson:"lifted_by,omitempty" json:"lifted_by,omitempty"`
+	LiftedAt   *time.Time          `bson:"lifted_at,omitempty" json:"lifted_at,omitempty"`
+	LiftReason string              `bson:"lift_reason,omitempty" json:"lift_reason,omitempty"`
+}
+
+// Active reports whether the sanction is in force at the given time.
+func (s Sanction) Active(now time.Time) bool {
+	return s.LiftedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
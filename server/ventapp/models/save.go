@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Save is a user's bookmark of a vent. Folder is optional and groups saves
+// in the user's saved list.
+type Save struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	VentID    primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	Folder    string             `bson:"folder" json:"folder"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// SavedVent is an entry in a user's saved list.
+type SavedVent struct {
+	Vent    Vent      `json:"vent"`
+	Folder  string    `json:"folder"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SaveFolder summarises one folder of a user's saved list.
+type SaveFolder struct {
+	Name  string `bson:"_id" json:"name"`
+	Count int    `bson:"count" json:"count"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchHit is one vent or reply matching a search. VentID is the vent the
+// hit belongs to (the hit itself for vents); Tags and Kind are the vent's.
+// Snippet is an excerpt around the first match, and Highlights are the
+// [start, end) rune offsets of matched terms within it. Rank orders results
+// by relevance and recency.
+type SearchHit struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	TargetType string             `bson:"target_type" json:"target_type"`
+	VentID     primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	Content    string             `bson:"content" json:"-"`
+	Tags       []string           `bson:"tags" json:"tags"`
+	Kind       string             `bson:"kind" json:"kind,omitempty"`
+	Snippet    string             `bson:"-" json:"snippet"`
+	Highlights [][2]int           `bson:"-" json:"highlights"`
+	Rank       float64            `bson:"rank" json:"-"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
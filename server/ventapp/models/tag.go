@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tag is an entry in the tag directory. Names are stored normalised. A tag
+// merged into another has MergedInto set and is stored as that tag from then
+// on; a banned tag may not be used at all.
+type Tag struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name          string              `bson:"name" json:"name"`
+	Description   string              `bson:"description" json:"description"`
+	VentCount     int                 `bson:"vent_count" json:"vent_count"`
+	FollowerCount int                 `bson:"follower_count" json:"follower_count"`
+	MergedInto    string              `bson:"merged_into,omitempty" json:"merged_into,omitempty"`
+	Banned        bool                `bson:"banned" json:"banned"`
+	UpdatedBy     *primitive.ObjectID `bson:"updated_by,omitempty" json:"-"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Active reports whether the tag can be used and followed.
+func (t *Tag) Active() bool {
+	return !t.Banned && t.MergedInto == ""
+}
+
+// TagFollow records that a user follows a tag.
+type TagFollow struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	Tag       string             `bson:"tag" json:"tag"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
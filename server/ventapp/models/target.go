@@ -0,0 +1,9 @@
+package models
+
+// Target types name what can be voted on, reported or moderated. Users can
+// only be moderated.
+const (
+	TargetVent  = "vent"
+	TargetReply = "reply"
+	TargetUser  = "user"
+)
@@ -0,0 +1,14 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// University is a campus vents can be scoped to. Country is an ISO 3166-1
+// alpha-2 code, used to pick local crisis resources.
+type University struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Location    string             `bson:"location" json:"location"`
+	Website     string             `bson:"website" json:"website"`
+	Established int                `bson:"established" json:"established"`
+	Country     string             `bson:"country,omitempty" json:"country,omitempty"`
+}
@@ -7,14 +7,35 @@ import (
 )
 
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Email        string             `bson:"email,omitempty" json:"email"`
-	PasswordHash string             `bson:"password_hash,omitempty" json:"-"`
-	TelegramID   int64              `bson:"telegram_id" json:"telegram_id"`
-	Username     string             `bson:"username" json:"username"`
-	DisplayName  string             `bson:"display_name" json:"display_name"`
-	AvatarURL    string             `bson:"avatar_url" json:"avatar_url"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	LastSeenAt   time.Time          `bson:"last_seen_at" json:"last_seen_at"`
-	IsAdmin      bool               `bson:"is_admin" json:"is_admin"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Email        string               `bson:"email,omitempty" json:"email"`
+	PasswordHash string               `bson:"password_hash,omitempty" json:"-"`
+	TelegramID   int64                `bson:"telegram_id" json:"telegram_id"`
+	BotBlocked   bool                 `bson:"bot_blocked,omitempty" json:"-"`
+	Username     string               `bson:"username" json:"username"`
+	DisplayName  string               `bson:"display_name" json:"display_name"`
+	AvatarURL    string               `bson:"avatar_url" json:"avatar_url"`
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+	LastSeenAt   time.Time            `bson:"last_seen_at" json:"last_seen_at"`
+	IsAdmin      bool                 `bson:"is_admin" json:"is_admin"`
+	UniversityID *primitive.ObjectID  `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	DepartmentID *primitive.ObjectID  `bson:"department_id,omitempty" json:"department_id,omitempty"`
+	CourseIDs    []primitive.ObjectID `bson:"course_ids,omitempty" json:"course_ids,omitempty"`
+}
+
+// AuthorProfile is the public view of a user shown next to their content.
+type AuthorProfile struct {
+	ID          *primitive.ObjectID `json:"id,omitempty"`
+	Username    string              `json:"username,omitempty"`
+	DisplayName string              `json:"display_name"`
+	AvatarURL   string              `json:"avatar_url,omitempty"`
+}
+
+// AnonymousAuthor stands in for the author of anonymous content.
+var AnonymousAuthor = AuthorProfile{DisplayName: "Anonymous"}
+
+// Profile returns the user's public author profile.
+func (u User) Profile() AuthorProfile {
+	id := u.ID
+	return AuthorProfile{ID: &id, Username: u.Username, DisplayName: u.DisplayName, AvatarURL: u.AvatarURL}
 }
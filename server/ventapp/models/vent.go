@@ -1,22 +1,75 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Post kinds
+const (
+	KindVent     = "vent"
+	KindQuestion = "question"
+)
+
 type Vent struct {
-	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	AuthorID  primitive.ObjectID   `bson:"author_id" json:"author_id"`
-	Content   string               `bson:"content" json:"content"`
-	Tags      []string             `bson:"tags" json:"tags"`
-	Upvotes   int                  `bson:"upvotes" json:"upvotes"`
-	Downvotes int                  `bson:"downvotes" json:"downvotes"`
-	Views     int                  `bson:"views" json:"views"`
-	SavedBy   []primitive.ObjectID `bson:"saved_by" json:"saved_by"`
-	Reports   []primitive.ObjectID `bson:"reports" json:"reports"`
-	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
-	IsDeleted bool                 `bson:"is_deleted" json:"is_deleted"`
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	AuthorID        primitive.ObjectID  `bson:"author_id" json:"author_id"`
+	Anonymous       bool                `bson:"anonymous" json:"anonymous"`
+	Kind            string              `bson:"kind" json:"kind"`
+	Content         string              `bson:"content" json:"content"`
+	Tags            []string            `bson:"tags" json:"tags"`
+	UniversityID    *primitive.ObjectID `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	DepartmentID    *primitive.ObjectID `bson:"department_id,omitempty" json:"department_id,omitempty"`
+	CourseID        *primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"`
+	Upvotes         int                 `bson:"upvotes" json:"upvotes"`
+	Downvotes       int                 `bson:"downvotes" json:"downvotes"`
+	Views           int                 `bson:"views" json:"views"`
+	Score           int                 `bson:"score" json:"score"`
+	ReplyCount      int                 `bson:"reply_count" json:"reply_count"`
+	AcceptedReplyID *primitive.ObjectID `bson:"accepted_reply_id,omitempty" json:"accepted_reply_id,omitempty"`
+	HotScore        float64             `bson:"hot_score" json:"hot_score"`
+	TrendingScore   float64             `bson:"trending_score" json:"trending_score"`
+	SaveCount       int                 `bson:"save_count" json:"save_count"`
+	ReportCount     int                 `bson:"report_count" json:"-"`
+	ReportWeight    float64             `bson:"report_weight" json:"-"`
+	UnderReview     bool                `bson:"under_review" json:"under_review"`
+	ShadowedUntil   *time.Time          `bson:"shadowed_until,omitempty" json:"-"`
+	Simhash         int64               `bson:"simhash,omitempty" json:"-"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
+	IsDeleted       bool                `bson:"is_deleted" json:"is_deleted"`
+	EditCount       int                 `bson:"edit_count" json:"edit_count"`
+	DeletedBy       *primitive.ObjectID `bson:"deleted_by,omitempty" json:"-"`
+	DeletedAt       *time.Time          `bson:"deleted_at,omitempty" json:"-"`
+	DeleteReason    string              `bson:"delete_reason,omitempty" json:"-"`
+}
+
+// VisibleTo reports whether a vent by a shadow-banned author may be shown to
+// the viewer, which is only the author themselves while the ban lasts.
+// viewerID is nil for anonymous viewers.
+func (v Vent) VisibleTo(viewerID *primitive.ObjectID) bool {
+	if v.ShadowedUntil == nil || !v.ShadowedUntil.After(time.Now()) {
+		return true
+	}
+	return viewerID != nil && *viewerID == v.AuthorID
+}
+
+// Scope returns where the vent lives, for scoped permission checks.
+func (v Vent) Scope() ResourceScope {
+	return ResourceScope{UniversityID: v.UniversityID, DepartmentID: v.DepartmentID, CourseID: v.CourseID}
+}
+
+// MarshalJSON leaves author_id out for anonymous vents. The real author is
+// still stored so moderators can act on it.
+func (v Vent) MarshalJSON() ([]byte, error) {
+	type vent Vent
+	if !v.Anonymous {
+		return json.Marshal(vent(v))
+	}
+	return json.Marshal(struct {
+		vent
+		AuthorID *primitive.ObjectID `json:"author_id,omitempty"`
+	}{vent: vent(v)})
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VentActivity counts net votes a vent received during one time bucket. It
+// feeds the trending sort and is pruned once it falls out of the window.
+type VentActivity struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	VentID primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	Bucket time.Time          `bson:"bucket" json:"bucket"`
+	Votes  int                `bson:"votes" json:"votes"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VentRevision is a vent's content as it was before an edit.
+type VentRevision struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	VentID    primitive.ObjectID `bson:"vent_id" json:"vent_id"`
+	Version   int                `bson:"version" json:"version"`
+	Content   string             `bson:"content" json:"content"`
+	Tags      []string           `bson:"tags" json:"tags"`
+	EditedBy  primitive.ObjectID `bson:"edited_by" json:"edited_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AppealRepository struct{ col string }
+
+func NewAppealRepository() *AppealRepository { return &AppealRepository{col: "appeals"} }
+
+// EnsureIndexes allows one appeal per moderation action and backs the admin
+// queue, which pages by status newest first.
+func (r *AppealRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "action_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+func (r *AppealRepository) Create(ctx context.Context, a *models.Appeal) error {
+	a.ID = primitive.NewObjectID()
+	a.CreatedAt = time.Now()
+	a.Status = models.AppealPending
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, a)
+	return err
+}
+
+func (r *AppealRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Appeal, error) {
+	var a models.Appeal
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AppealFilter narrows the appeal queue. Empty fields are not filtered on.
+// ContentScopes and UserScopes limit appeals against content and against
+// users to those inside the given role bindings; nil means every scope.
+type AppealFilter struct {
+	Status        string
+	UserID        *primitive.ObjectID
+	ContentScopes []models.RoleBinding
+	UserScopes    []models.RoleBinding
+}
+
+// FindPage returns one page of appeals matching filter, newest first.
+func (r *AppealRepository) FindPage(ctx context.Context, f AppealFilter, after *Cursor, limit int64) (models.Page[models.Appeal], error) {
+	filter := bson.M{}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.UserID != nil {
+		filter["user_id"] = *f.UserID
+	}
+	content := bson.M{"target_type": bson.M{"$ne": models.TargetUser}}
+	restrictToScopes(content, f.ContentScopes)
+	users := bson.M{"target_type": models.TargetUser}
+	restrictToScopes(users, f.UserScopes)
+	filter["$or"] = bson.A{content, users}
+	return findPage(ctx, config.DB.Collection(r.col), filter, PageRequest{After: after, Limit: limit}, func(a models.Appeal) Cursor {
+		return Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	})
+}
+
+// Decide records the decision on a pending appeal. It returns
+// mongo.ErrNoDocuments if the appeal has already been decided.
+func (r *AppealRepository) Decide(ctx context.Context, id primitive.ObjectID, status string, by primitive.ObjectID, decision string) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.AppealPending},
+		bson.M{"$set": bson.M{
+			"status":     status,
+			"decided_by": by,
+			"decided_at": time.Now(),
+			"decision":   decision,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Reopen puts an appeal the moderator decided back to pending, for when
+// acting on the decision failed.
+func (r *AppealRepository) Reopen(ctx context.Context, id, by primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "decided_by": by, "status": bson.M{"$ne": models.AppealPending}},
+		bson.M{
+			"$set":   bson.M{"status": models.AppealPending},
+			"$unset": bson.M{"decided_by": "", "decided_at": "", "decision": ""},
+		},
+	)
+	return err
+}
+
+// BackfillTargetTypes copies the target type of the appealed action onto
+// appeals filed before appeals carried it.
+func (r *AppealRepository) BackfillTargetTypes(ctx context.Context) error {
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"target_type": bson.M{"$exists": false}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "moderation_actions", "localField": "action_id", "foreignField": "_id", "as": "action"}}},
+		{{Key: "$unwind", Value: "$action"}},
+		{{Key: "$project", Value: bson.M{"target_type": "$action.target_type"}}},
+		{{Key: "$merge", Value: bson.M{"into": r.col, "on": "_id", "whenMatched": "merge", "whenNotMatched": "discard"}}},
+	})
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
@@ -0,0 +1,337 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Exclusions are what a viewer has chosen not to see: content by the Authors
+// they blocked or muted, vents carrying any of Tags and the Vents they hid.
+type Exclusions struct {
+	Authors []primitive.ObjectID
+	Tags    []string
+	Vents   []primitive.ObjectID
+}
+
+// excludeVents restricts filter to vents the exclusions do not cover, with
+// vent field names under prefix.
+func (e Exclusions) excludeVents(filter bson.M, prefix string) {
+	var nor bson.A
+	if len(e.Authors) > 0 {
+		nor = append(nor, bson.M{prefix + "author_id": bson.M{"$in": e.Authors}})
+	}
+	if len(e.Tags) > 0 {
+		nor = append(nor, bson.M{prefix + "tags": bson.M{"$in": e.Tags}})
+	}
+	if len(e.Vents) > 0 {
+		nor = append(nor, bson.M{prefix + "_id": bson.M{"$in": e.Vents}})
+	}
+	if len(nor) > 0 {
+		filter["$nor"] = nor
+	}
+}
+
+// excludeAuthors restricts filter to replies by authors the exclusions do
+// not cover.
+func (e Exclusions) excludeAuthors(filter bson.M) {
+	if len(e.Authors) > 0 {
+		filter["author_id"] = bson.M{"$nin": e.Authors}
+	}
+}
+
+// tombstoneAuthors restricts filter to replies by authors the exclusions do
+// not cover and to replies by covered authors that have been answered, which
+// callers show as tombstones so the answers stay reachable.
+func (e Exclusions) tombstoneAuthors(filter bson.M) {
+	if len(e.Authors) > 0 {
+		filter["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"author_id": bson.M{"$nin": e.Authors}},
+			bson.M{"reply_count": bson.M{"$gt": 0}},
+		}}}
+	}
+}
+
+// Hides reports whether the exclusions cover a vent.
+func (e Exclusions) Hides(v models.Vent) bool {
+	if slices.Contains(e.Authors, v.AuthorID) || slices.Contains(e.Vents, v.ID) {
+		return true
+	}
+	for _, tag := range v.Tags {
+		if slices.Contains(e.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// distinctIDs returns the distinct id values of field in the documents
+// matching filter.
+func distinctIDs(ctx context.Context, col *mongo.Collection, field string, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := col.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+type RelationRepository struct{ col string }
+
+func NewRelationRepository() *RelationRepository {
+	return &RelationRepository{col: "user_relations"}
+}
+
+// EnsureIndexes allows one relation per pair of users and backs the lookup
+// of who blocked or muted a user.
+func (r *RelationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "target_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "kind", Value: 1}}},
+	})
+	return err
+}
+
+// Block makes the user block the target, replacing a mute.
+func (r *RelationRepository) Block(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "target_id": targetID},
+		bson.M{
+			"$set":         bson.M{"kind": models.RelationBlock, "created_at": time.Now()},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Mute makes the user mute the target. A block is left in place.
+func (r *RelationRepository) Mute(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "target_id": targetID},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"kind":       models.RelationMute,
+			"created_at": time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete removes the user's relation of the given kind to the target and
+// reports whether one existed.
+func (r *RelationRepository) Delete(ctx context.Context, userID, targetID primitive.ObjectID, kind string) (bool, error) {
+	res, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"user_id": userID, "target_id": targetID, "kind": kind})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// FindByUser returns the user's relations of the given kind, or of every
+// kind when kind is empty, newest first.
+func (r *RelationRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, kind string) ([]models.Relation, error) {
+	filter := bson.M{"user_id": userID}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	cursor, err := config.DB.Collection(r.col).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	relations := []models.Relation{}
+	if err := cursor.All(ctx, &relations); err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// Blocks reports whether any of the users blocked the target.
+func (r *RelationRepository) Blocks(ctx context.Context, userIDs []primitive.ObjectID, targetID primitive.ObjectID) (bool, error) {
+	n, err := config.DB.Collection(r.col).CountDocuments(ctx,
+		bson.M{"user_id": bson.M{"$in": userIDs}, "target_id": targetID, "kind": models.RelationBlock},
+		options.Count().SetLimit(1),
+	)
+	return n > 0, err
+}
+
+// Excludes reports whether the user blocked or muted the target.
+func (r *RelationRepository) Excludes(ctx context.Context, userID, targetID primitive.ObjectID) (bool, error) {
+	n, err := config.DB.Collection(r.col).CountDocuments(ctx,
+		bson.M{"user_id": userID, "target_id": targetID},
+		options.Count().SetLimit(1),
+	)
+	return n > 0, err
+}
+
+// UserIDsByTargets returns the users who blocked or muted any of the targets.
+func (r *RelationRepository) UserIDsByTargets(ctx context.Context, targetIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, config.DB.Collection(r.col), "user_id", bson.M{"target_id": bson.M{"$in": targetIDs}})
+}
+
+type MutedTagRepository struct{ col string }
+
+func NewMutedTagRepository() *MutedTagRepository { return &MutedTagRepository{col: "muted_tags"} }
+
+// EnsureIndexes allows one mute per user per tag and backs the lookup of who
+// muted a tag.
+func (r *MutedTagRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "tag", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tag", Value: 1}}},
+	})
+	return err
+}
+
+// Upsert makes the user mute the tag.
+func (r *MutedTagRepository) Upsert(ctx context.Context, userID primitive.ObjectID, tag string) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "tag": tag},
+		bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete removes the user's mute of the tag.
+func (r *MutedTagRepository) Delete(ctx context.Context, userID primitive.ObjectID, tag string) error {
+	_, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"user_id": userID, "tag": tag})
+	return err
+}
+
+// FindByUser returns the tags the user muted, newest first.
+func (r *MutedTagRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.MutedTag, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	muted := []models.MutedTag{}
+	if err := cursor.All(ctx, &muted); err != nil {
+		return nil, err
+	}
+	return muted, nil
+}
+
+// UserIDsByTags returns the users who muted any of the tags.
+func (r *MutedTagRepository) UserIDsByTags(ctx context.Context, tags []string) ([]primitive.ObjectID, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return distinctIDs(ctx, config.DB.Collection(r.col), "user_id", bson.M{"tag": bson.M{"$in": tags}})
+}
+
+// Move transfers mutes of one tag to another, keeping a single mute for
+// users who muted both.
+func (r *MutedTagRepository) Move(ctx context.Context, from, into string) error {
+	col := config.DB.Collection(r.col)
+	cursor, err := col.Find(ctx, bson.M{"tag": from})
+	if err != nil {
+		return err
+	}
+	var muted []models.MutedTag
+	if err := cursor.All(ctx, &muted); err != nil {
+		return err
+	}
+
+	if len(muted) > 0 {
+		writes := make([]mongo.WriteModel, 0, len(muted))
+		for _, m := range muted {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"user_id": m.UserID, "tag": into}).
+				SetUpdate(bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": m.CreatedAt}}).
+				SetUpsert(true))
+		}
+		if _, err := col.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	_, err = col.DeleteMany(ctx, bson.M{"tag": from})
+	return err
+}
+
+type HiddenVentRepository struct{ col string }
+
+func NewHiddenVentRepository() *HiddenVentRepository {
+	return &HiddenVentRepository{col: "hidden_vents"}
+}
+
+// EnsureIndexes allows one record per user per vent and backs the lookup of
+// who hid a vent.
+func (r *HiddenVentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "vent_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "vent_id", Value: 1}}},
+	})
+	return err
+}
+
+// Upsert hides the vent from the user.
+func (r *HiddenVentRepository) Upsert(ctx context.Context, userID, ventID primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "vent_id": ventID},
+		bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete shows the vent to the user again.
+func (r *HiddenVentRepository) Delete(ctx context.Context, userID, ventID primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"user_id": userID, "vent_id": ventID})
+	return err
+}
+
+// FindByUser returns the vents the user hid, newest first.
+func (r *HiddenVentRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.HiddenVent, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hidden := []models.HiddenVent{}
+	if err := cursor.All(ctx, &hidden); err != nil {
+		return nil, err
+	}
+	return hidden, nil
+}
+
+// UserIDsByVent returns the users who hid the vent.
+func (r *HiddenVentRepository) UserIDsByVent(ctx context.Context, ventID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, config.DB.Collection(r.col), "user_id", bson.M{"vent_id": ventID})
+}
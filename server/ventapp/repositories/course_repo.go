@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CourseRepository struct{ col string }
+
+func NewCourseRepository() *CourseRepository { return &CourseRepository{col: "courses"} }
+
+func (r *CourseRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Course, error) {
+	var co models.Course
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&co); err != nil {
+		return nil, err
+	}
+	return &co, nil
+}
+
+// IDsByDepartments returns the ids of the courses in the departments.
+func (r *CourseRepository) IDsByDepartments(ctx context.Context, departmentIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, config.DB.Collection(r.col), "_id", bson.M{"department_id": bson.M{"$in": departmentIDs}})
+}
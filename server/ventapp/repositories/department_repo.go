@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DepartmentRepository struct{ col string }
+
+func NewDepartmentRepository() *DepartmentRepository {
+	return &DepartmentRepository{col: "departments"}
+}
+
+func (r *DepartmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Department, error) {
+	var d models.Department
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// IDsByUniversities returns the ids of the departments in the universities.
+func (r *DepartmentRepository) IDsByUniversities(ctx context.Context, universityIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, config.DB.Collection(r.col), "_id", bson.M{"university_id": bson.M{"$in": universityIDs}})
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FeedSessionRepository struct{ col string }
+
+func NewFeedSessionRepository() *FeedSessionRepository {
+	return &FeedSessionRepository{col: "feed_sessions"}
+}
+
+// EnsureIndexes backs pruning a user's expired sessions.
+func (r *FeedSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func (r *FeedSessionRepository) Create(ctx context.Context, s *models.FeedSession) error {
+	s.ID = primitive.NewObjectID()
+	s.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, s)
+	return err
+}
+
+// FindByID returns one of the user's sessions created since the given time.
+func (r *FeedSessionRepository) FindByID(ctx context.Context, id, userID primitive.ObjectID, since time.Time) (*models.FeedSession, error) {
+	var s models.FeedSession
+	err := config.DB.Collection(r.col).FindOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "created_at": bson.M{"$gte": since}},
+	).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteBefore removes the user's sessions created before t.
+func (r *FeedSessionRepository) DeleteBefore(ctx context.Context, userID primitive.ObjectID, t time.Time) error {
+	_, err := config.DB.Collection(r.col).DeleteMany(ctx, bson.M{"user_id": userID, "created_at": bson.M{"$lt": t}})
+	return err
+}
+
+type SeenVentRepository struct{ col string }
+
+func NewSeenVentRepository() *SeenVentRepository { return &SeenVentRepository{col: "seen_vents"} }
+
+// EnsureIndexes keeps one record per user per vent and backs the lookup of
+// what a user has seen recently.
+func (r *SeenVentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "vent_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "seen_at", Value: -1}}},
+	})
+	return err
+}
+
+// Mark records that the user has seen the vents now.
+func (r *SeenVentRepository) Mark(ctx context.Context, userID primitive.ObjectID, ventIDs []primitive.ObjectID) error {
+	if len(ventIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(ventIDs))
+	for _, id := range ventIDs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": userID, "vent_id": id}).
+			SetUpdate(bson.M{
+				"$set":         bson.M{"seen_at": now},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+			}).
+			SetUpsert(true))
+	}
+	_, err := config.DB.Collection(r.col).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// DeleteBefore forgets what the user saw before t.
+func (r *SeenVentRepository) DeleteBefore(ctx context.Context, userID primitive.ObjectID, t time.Time) error {
+	_, err := config.DB.Collection(r.col).DeleteMany(ctx, bson.M{"user_id": userID, "seen_at": bson.M{"$lt": t}})
+	return err
+}
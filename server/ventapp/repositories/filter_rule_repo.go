@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FilterRuleRepository struct{ col string }
+
+func NewFilterRuleRepository() *FilterRuleRepository {
+	return &FilterRuleRepository{col: "filter_rules"}
+}
+
+func (r *FilterRuleRepository) Create(ctx context.Context, rule *models.FilterRule) error {
+	rule.ID = primitive.NewObjectID()
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, rule)
+	return err
+}
+
+func (r *FilterRuleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.FilterRule, error) {
+	var rule models.FilterRule
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindAll returns every rule, oldest first, which is the order they run in.
+func (r *FilterRuleRepository) FindAll(ctx context.Context) ([]models.FilterRule, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []models.FilterRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Count returns the number of rules, enabled or not.
+func (r *FilterRuleRepository) Count(ctx context.Context) (int64, error) {
+	return config.DB.Collection(r.col).CountDocuments(ctx, bson.M{})
+}
+
+// Replace overwrites a rule's editable fields.
+func (r *FilterRuleRepository) Replace(ctx context.Context, rule *models.FilterRule) error {
+	rule.UpdatedAt = time.Now()
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": rule.ID},
+		bson.M{"$set": bson.M{
+			"name":       rule.Name,
+			"kind":       rule.Kind,
+			"action":     rule.Action,
+			"terms":      rule.Terms,
+			"pattern":    rule.Pattern,
+			"limit":      rule.Limit,
+			"enabled":    rule.Enabled,
+			"updated_by": rule.UpdatedBy,
+			"updated_at": rule.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *FilterRuleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
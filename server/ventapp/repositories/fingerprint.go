@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PostFingerprint is the part of a vent or reply duplicate detection needs.
+// VentID is the vent a reply belongs to, and zero for vents.
+type PostFingerprint struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	AuthorID primitive.ObjectID `bson:"author_id"`
+	VentID   primitive.ObjectID `bson:"vent_id,omitempty"`
+	Simhash  int64              `bson:"simhash"`
+}
+
+// recentFingerprints returns the fingerprints of live posts in col created
+// since the given time, newest first, by one author or by anyone when
+// authorID is nil.
+func recentFingerprints(ctx context.Context, col *mongo.Collection, authorID *primitive.ObjectID, since time.Time, limit int64) ([]PostFingerprint, error) {
+	filter := bson.M{
+		"is_deleted": false,
+		"created_at": bson.M{"$gte": since},
+		"simhash":    bson.M{"$exists": true},
+	}
+	if authorID != nil {
+		filter["author_id"] = *authorID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"_id": 1, "author_id": 1, "vent_id": 1, "simhash": 1})
+
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	prints := []PostFingerprint{}
+	if err := cursor.All(ctx, &prints); err != nil {
+		return nil, err
+	}
+	return prints, nil
+}
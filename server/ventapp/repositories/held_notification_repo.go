@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HeldNotificationRepository struct{ col string }
+
+func NewHeldNotificationRepository() *HeldNotificationRepository {
+	return &HeldNotificationRepository{col: "held_notifications"}
+}
+
+// EnsureIndexes backs the release job's scan for notifications due.
+func (r *HeldNotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "release_at", Value: 1}},
+	})
+	return err
+}
+
+// Hold keeps n back until releaseAt. A stored notification is held once:
+// holding it again, e.g. after its group gained actors, replaces the copy.
+func (r *HeldNotificationRepository) Hold(ctx context.Context, n models.Notification, releaseAt time.Time) error {
+	id := n.ID
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"user_id": n.UserID, "notification": n, "release_at": releaseAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// TakeDue removes and returns the longest-held notification due by now, or
+// mongo.ErrNoDocuments if none is, so only one server sends each.
+func (r *HeldNotificationRepository) TakeDue(ctx context.Context, now time.Time) (*models.HeldNotification, error) {
+	var h models.HeldNotification
+	err := config.DB.Collection(r.col).FindOneAndDelete(ctx,
+		bson.M{"release_at": bson.M{"$lte": now}},
+		options.FindOneAndDelete().SetSort(bson.D{{Key: "release_at", Value: 1}}),
+	).Decode(&h)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
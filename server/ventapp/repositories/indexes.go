@@ -0,0 +1,36 @@
+package repositories
+
+import "context"
+
+// EnsureIndexes creates the indexes every repository relies on. It is safe to
+// call on each startup; existing indexes are left untouched.
+func EnsureIndexes(ctx context.Context) error {
+	for _, ensure := range []func(context.Context) error{
+		NewRoleRepository().EnsureIndexes,
+		NewVentRepository().EnsureIndexes,
+		NewVentActivityRepository().EnsureIndexes,
+		NewVoteRepository().EnsureIndexes,
+		NewVentRevisionRepository().EnsureIndexes,
+		NewReplyRepository().EnsureIndexes,
+		NewSaveRepository().EnsureIndexes,
+		NewReportRepository().EnsureIndexes,
+		NewModerationRepository().EnsureIndexes,
+		NewSanctionRepository().EnsureIndexes,
+		NewAppealRepository().EnsureIndexes,
+		NewTagRepository().EnsureIndexes,
+		NewTagFollowRepository().EnsureIndexes,
+		NewFeedSessionRepository().EnsureIndexes,
+		NewSeenVentRepository().EnsureIndexes,
+		NewRelationRepository().EnsureIndexes,
+		NewMutedTagRepository().EnsureIndexes,
+		NewHiddenVentRepository().EnsureIndexes,
+		NewNotificationRepository().EnsureIndexes,
+		NewNotificationPrefsRepository().EnsureIndexes,
+		NewHeldNotificationRepository().EnsureIndexes,
+	} {
+		if err := ensure(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationRepository records which data migrations have been applied.
+type MigrationRepository struct{ col string }
+
+func NewMigrationRepository() *MigrationRepository { return &MigrationRepository{col: "migrations"} }
+
+// Applied reports whether the named migration has been recorded.
+func (r *MigrationRepository) Applied(ctx context.Context, name string) (bool, error) {
+	err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": name}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Record marks the named migration applied.
+func (r *MigrationRepository) Record(ctx context.Context, name string) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$setOnInsert": bson.M{"applied_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ModerationRepository stores the moderation log. Entries are only ever
+// appended: there is deliberately no way to update or delete one, and
+// reversing an action appends a new entry.
+type ModerationRepository struct{ col string }
+
+func NewModerationRepository() *ModerationRepository {
+	return &ModerationRepository{col: "moderation_actions"}
+}
+
+// EnsureIndexes backs the log views: by target, by actor, by affected user
+// and by scope, all newest first.
+func (r *ModerationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "subject_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "university_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "department_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ModerationRepository) Create(ctx context.Context, a *models.ModerationAction) error {
+	a.ID = primitive.NewObjectID()
+	a.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, a)
+	return err
+}
+
+func (r *ModerationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ModerationAction, error) {
+	var a models.ModerationAction
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ModerationFilter narrows the moderation log. Empty fields are not filtered
+// on. Scopes limits the log to entries inside the given role bindings; nil
+// means every scope.
+type ModerationFilter struct {
+	Action     string
+	TargetType string
+	TargetID   *primitive.ObjectID
+	ActorID    *primitive.ObjectID
+	SubjectID  *primitive.ObjectID
+	Scopes     []models.RoleBinding
+}
+
+func (f ModerationFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.TargetType != "" {
+		filter["target_type"] = f.TargetType
+	}
+	if f.TargetID != nil {
+		filter["target_id"] = *f.TargetID
+	}
+	if f.ActorID != nil {
+		filter["actor_id"] = *f.ActorID
+	}
+	if f.SubjectID != nil {
+		filter["subject_id"] = *f.SubjectID
+	}
+	restrictToScopes(filter, f.Scopes)
+	return filter
+}
+
+// FindPage returns one page of the log matching filter, newest first.
+func (r *ModerationRepository) FindPage(ctx context.Context, f ModerationFilter, after *Cursor, limit int64) (models.Page[models.ModerationAction], error) {
+	return findPage(ctx, config.DB.Collection(r.col), f.bson(), PageRequest{After: after, Limit: limit}, func(a models.ModerationAction) Cursor {
+		return Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	})
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationPrefsRepository struct{ col string }
+
+func NewNotificationPrefsRepository() *NotificationPrefsRepository {
+	return &NotificationPrefsRepository{col: "notification_prefs"}
+}
+
+// EnsureIndexes backs the digest job's scan for subscribers.
+func (r *NotificationPrefsRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "digest", Value: 1}},
+	})
+	return err
+}
+
+// FindByUser returns the user's settings, or mongo.ErrNoDocuments if they
+// never changed them.
+func (r *NotificationPrefsRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) (*models.NotificationPrefs, error) {
+	var p models.NotificationPrefs
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": userID}).Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save stores the user's settings. The digest schedule starts from the first
+// save, so subscribing never sends a digest covering the time before it.
+func (r *NotificationPrefsRepository) Save(ctx context.Context, p *models.NotificationPrefs) error {
+	now := time.Now()
+	p.UpdatedAt = now
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": p.UserID},
+		bson.M{
+			"$set": bson.M{
+				"types":       p.Types,
+				"quiet_hours": p.QuietHours,
+				"timezone":    p.Timezone,
+				"digest":      p.Digest,
+				"updated_at":  now,
+			},
+			"$setOnInsert": bson.M{"last_digest_at": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// EachDigestSubscriber calls fn with the settings of every user subscribed
+// to a digest, stopping at the first error.
+func (r *NotificationPrefsRepository) EachDigestSubscriber(ctx context.Context, fn func(models.NotificationPrefs) error) error {
+	cursor, err := config.DB.Collection(r.col).Find(ctx,
+		bson.M{"digest": bson.M{"$in": bson.A{models.DigestDaily, models.DigestWeekly}}},
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var p models.NotificationPrefs
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ClaimDigest moves the user's last digest time from prev to at and reports
+// whether it did, so only one server sends each digest.
+func (r *NotificationPrefsRepository) ClaimDigest(ctx context.Context, userID primitive.ObjectID, prev *time.Time, at time.Time) (bool, error) {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": userID, "last_digest_at": prev},
+		bson.M{"$set": bson.M{"last_digest_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
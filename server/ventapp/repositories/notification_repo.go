@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxGroupActors is how many actors a grouped notification remembers to
+// avoid counting the same user twice. Past it, a user acting again may be
+// counted again.
+const maxGroupActors = 100
+
+type NotificationRepository struct{ col string }
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{col: "notifications"}
+}
+
+// EnsureIndexes backs listing and counting a user's notifications and
+// allows one unread group per user, type and target.
+func (r *NotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "type", Value: 1},
+				{Key: "target_type", Value: 1},
+				{Key: "target_id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"grouped": true, "read": false}),
+		},
+	})
+	return err
+}
+
+// Create stores a notification on its own.
+func (r *NotificationRepository) Create(ctx context.Context, n *models.Notification) error {
+	n.ID = primitive.NewObjectID()
+	n.Grouped = false
+	n.Read = false
+	n.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, n)
+	return err
+}
+
+// Group adds the actor to the user's unread notification of the same type
+// on the same target, creating it if there is none, and returns it. Repeat
+// actors are not counted twice. The notification moves to the top of the
+// user's list.
+func (r *NotificationRepository) Group(ctx context.Context, n *models.Notification, actorID primitive.ObjectID) (*models.Notification, error) {
+	actors := bson.M{"$ifNull": bson.A{"$actor_ids", bson.A{}}}
+	seen := bson.M{"$in": bson.A{actorID, actors}}
+	set := bson.M{
+		"actor_ids": bson.M{"$slice": bson.A{
+			bson.M{"$cond": bson.A{seen, actors, bson.M{"$concatArrays": bson.A{actors, bson.A{actorID}}}}},
+			-maxGroupActors,
+		}},
+		"actor_count": bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$actor_count", 0}},
+			bson.M{"$cond": bson.A{seen, 0, 1}},
+		}},
+		"created_at": time.Now(),
+	}
+	if n.VentID != nil {
+		set["vent_id"] = *n.VentID
+	}
+
+	var grouped models.Notification
+	err := config.DB.Collection(r.col).FindOneAndUpdate(ctx,
+		bson.M{
+			"user_id":     n.UserID,
+			"type":        n.Type,
+			"target_type": n.TargetType,
+			"target_id":   n.TargetID,
+			"grouped":     true,
+			"read":        false,
+		},
+		mongo.Pipeline{{{Key: "$set", Value: set}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&grouped)
+	if err != nil {
+		return nil, err
+	}
+	return &grouped, nil
+}
+
+// FindPage returns one page of the user's notifications, newest first,
+// optionally only the unread ones.
+func (r *NotificationRepository) FindPage(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after *Cursor, limit int64) (models.Page[models.Notification], error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	p := PageRequest{After: after, Limit: limit}
+	return findPage(ctx, config.DB.Collection(r.col), filter, p, func(n models.Notification) Cursor {
+		return Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+	})
+}
+
+// CountUnread returns how many of the user's notifications are unread.
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return config.DB.Collection(r.col).CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+}
+
+// MarkRead marks one of the user's notifications read. A notification of
+// another user is reported as missing.
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// MarkAllRead marks the user's notifications created up to t read and
+// returns how many were unread.
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, t time.Time) (int64, error) {
+	res, err := config.DB.Collection(r.col).UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false, "created_at": bson.M{"$lte": t}},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// FindSince returns up to limit of the user's notifications of a type that
+// arrived since the given time, newest first.
+func (r *NotificationRepository) FindSince(ctx context.Context, userID primitive.ObjectID, notificationType string, since time.Time, limit int64) ([]models.Notification, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx,
+		bson.M{"user_id": userID, "type": notificationType, "created_at": bson.M{"$gte": since}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor is returned when a client sends a cursor we did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item on a page. Value holds the primary
+// sort key when a page is sorted by something other than created_at, and Sort
+// names the ordering the cursor belongs to.
+type Cursor struct {
+	Sort      string             `json:"s,omitempty"`
+	Value     float64            `json:"v,omitempty"`
+	CreatedAt time.Time          `json:"t"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// PageRequest describes one page of a keyset scan over (SortField,
+// created_at, _id), newest first unless Ascending is set. An empty SortField
+// pages by created_at alone. Sort names the ordering when one listing offers
+// several; cursors carry it, and a cursor issued for another ordering is
+// rejected with ErrInvalidCursor.
+type PageRequest struct {
+	Sort      string
+	SortField string
+	Ascending bool
+	After     *Cursor
+	Limit     int64
+}
+
+// check rejects a cursor issued for another ordering.
+func (p PageRequest) check() error {
+	if p.After != nil && p.After.Sort != p.Sort {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// next returns the cursor for the item that ends a page.
+func (p PageRequest) next(c Cursor) string {
+	c.Sort = p.Sort
+	return c.Encode()
+}
+
+func (p PageRequest) limit() int64 {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+func (p PageRequest) sort() bson.D {
+	dir := -1
+	if p.Ascending {
+		dir = 1
+	}
+	sort := bson.D{}
+	if p.SortField != "" {
+		sort = append(sort, bson.E{Key: p.SortField, Value: dir})
+	}
+	return append(sort, bson.E{Key: "created_at", Value: dir}, bson.E{Key: "_id", Value: dir})
+}
+
+// seek restricts filter to items strictly after the cursor in sort order.
+func (p PageRequest) seek(filter bson.M) bson.M {
+	if p.After == nil {
+		return filter
+	}
+	a := p.After
+	past := "$lt"
+	if p.Ascending {
+		past = "$gt"
+	}
+	afterTime := bson.A{
+		bson.M{"created_at": bson.M{past: a.CreatedAt}},
+		bson.M{"created_at": a.CreatedAt, "_id": bson.M{past: a.ID}},
+	}
+	var seek bson.M
+	if p.SortField == "" {
+		seek = bson.M{"$or": afterTime}
+	} else {
+		seek = bson.M{"$or": bson.A{
+			bson.M{p.SortField: bson.M{past: a.Value}},
+			bson.M{p.SortField: a.Value, "$or": afterTime},
+		}}
+	}
+	return bson.M{"$and": bson.A{filter, seek}}
+}
+
+// findPage runs a keyset-paginated query. key must return the cursor for an
+// item using the same SortField the request was built with.
+func findPage[T any](ctx context.Context, col *mongo.Collection, filter bson.M, p PageRequest, key func(T) Cursor) (models.Page[T], error) {
+	return runPage(ctx, p, key, func(limit int64) (*mongo.Cursor, error) {
+		opts := &options.FindOptions{}
+		opts.SetSort(p.sort())
+		opts.SetLimit(limit)
+		return col.Find(ctx, p.seek(filter), opts)
+	})
+}
+
+// aggregatePage is findPage for aggregation results. The pipeline must emit
+// documents carrying created_at, _id and, if set, the request's SortField.
+func aggregatePage[T any](ctx context.Context, col *mongo.Collection, pipeline mongo.Pipeline, p PageRequest, key func(T) Cursor) (models.Page[T], error) {
+	return runPage(ctx, p, key, func(limit int64) (*mongo.Cursor, error) {
+		return col.Aggregate(ctx, append(pipeline,
+			bson.D{{Key: "$match", Value: p.seek(bson.M{})}},
+			bson.D{{Key: "$sort", Value: p.sort()}},
+			bson.D{{Key: "$limit", Value: limit}},
+		))
+	})
+}
+
+// runPage reads a page from the query open runs, which must return at most
+// limit items in the request's order. One item more than the page holds is
+// asked for to tell whether another page follows.
+func runPage[T any](ctx context.Context, p PageRequest, key func(T) Cursor, open func(limit int64) (*mongo.Cursor, error)) (models.Page[T], error) {
+	if err := p.check(); err != nil {
+		return models.Page[T]{}, err
+	}
+	cursor, err := open(p.limit() + 1)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+	defer cursor.Close(ctx)
+
+	items := make([]T, 0, p.limit()+1)
+	if err := cursor.All(ctx, &items); err != nil {
+		return models.Page[T]{}, err
+	}
+	return pageOf(p, items, key), nil
+}
+
+// pageOf makes a page of the items read for a request, which may run one
+// item past the page to show that more follow.
+func pageOf[T any](p PageRequest, items []T, key func(T) Cursor) models.Page[T] {
+	limit := p.limit()
+	page := models.Page[T]{Items: items}
+	if int64(len(items)) > limit {
+		page.Items = items[:limit]
+		page.HasMore = true
+		page.NextCursor = p.next(key(page.Items[limit-1]))
+	}
+	return page
+}
@@ -7,13 +7,28 @@ import (
 	"ventapp/server/ventapp/config"
 	"ventapp/server/ventapp/models"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ReplyRepository struct{ col string }
 
 func NewReplyRepository() *ReplyRepository { return &ReplyRepository{col: "replies"} }
 
+// EnsureIndexes backs paging through one branch of a thread in posting
+// order, the lookups of recent replies used to detect duplicates, and search.
+func (r *ReplyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "vent_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "created_at", Value: -1}}},
+		searchIndex(bson.D{{Key: "content", Value: "text"}}, bson.M{"content": 1}),
+	})
+	return err
+}
+
 func (r *ReplyRepository) Create(ctx context.Context, rep *models.Reply) error {
 	rep.ID = primitive.NewObjectID()
 	now := time.Now()
@@ -22,3 +37,256 @@ func (r *ReplyRepository) Create(ctx context.Context, rep *models.Reply) error {
 	_, err := config.DB.Collection(r.col).InsertOne(ctx, rep)
 	return err
 }
+
+// FindByID returns a reply, including tombstoned ones.
+func (r *ReplyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Reply, error) {
+	var rep models.Reply
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&rep); err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// FindChildren returns one page of the direct replies to parentID (or the
+// top-level replies when parentID is nil), oldest first. Tombstones are
+// included so their children stay reachable. An accepted answer is left out;
+// callers show it ahead of the other replies. Shadowed replies are only
+// included for their author; viewerID is nil for anonymous viewers. Replies
+// by authors in exclude are only included once answered, for callers to show
+// as tombstones.
+func (r *ReplyRepository) FindChildren(ctx context.Context, viewerID *primitive.ObjectID, exclude Exclusions, ventID primitive.ObjectID, parentID *primitive.ObjectID, after *Cursor, limit int64) (models.Page[models.Reply], error) {
+	// a nil parentID encodes as null, which matches the missing parent_id of top-level replies
+	filter := childFilter(viewerID, exclude, ventID)
+	filter["parent_id"] = parentID
+	p := PageRequest{Ascending: true, After: after, Limit: limit}
+	return findPage(ctx, config.DB.Collection(r.col), filter, p, replyCursor)
+}
+
+// FindChildrenOf returns the first page of direct replies to each of the
+// parents, keyed by parent, in one query. Replies are chosen and ordered as
+// by FindChildren; parents without visible replies are missing from the map.
+func (r *ReplyRepository) FindChildrenOf(ctx context.Context, viewerID *primitive.ObjectID, exclude Exclusions, ventID primitive.ObjectID, parentIDs []primitive.ObjectID, limit int64) (map[primitive.ObjectID]models.Page[models.Reply], error) {
+	p := PageRequest{Ascending: true, Limit: limit}
+	children := childFilter(viewerID, exclude, ventID)
+	children["$expr"] = bson.M{"$eq": bson.A{"$parent_id", "$$parent"}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": parentIDs}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": r.col,
+			"let":  bson.M{"parent": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": children},
+				bson.M{"$sort": p.sort()},
+				bson.M{"$limit": p.limit() + 1},
+			},
+			"as": "children",
+		}}},
+		{{Key: "$project", Value: bson.M{"children": 1}}},
+	}
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Children []models.Reply     `bson:"children"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	pages := make(map[primitive.ObjectID]models.Page[models.Reply], len(rows))
+	for _, row := range rows {
+		if len(row.Children) > 0 {
+			pages[row.ID] = pageOf(p, row.Children, replyCursor)
+		}
+	}
+	return pages, nil
+}
+
+// childFilter matches the replies in a vent's threads that FindChildren
+// lists for the viewer.
+func childFilter(viewerID *primitive.ObjectID, exclude Exclusions, ventID primitive.ObjectID) bson.M {
+	filter := bson.M{"vent_id": ventID, "accepted": bson.M{"$ne": true}}
+	hideShadowed(filter, viewerID)
+	exclude.tombstoneAuthors(filter)
+	return filter
+}
+
+func replyCursor(rep models.Reply) Cursor {
+	return Cursor{CreatedAt: rep.CreatedAt, ID: rep.ID}
+}
+
+// UpdateContent replaces a live reply's content.
+func (r *ReplyRepository) UpdateContent(ctx context.Context, id primitive.ObjectID, content string, simhash int64) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": false},
+		bson.M{"$set": bson.M{"content": content, "simhash": simhash, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RecentFingerprints returns the fingerprints of live replies posted since
+// the given time, newest first, by one author or by anyone when authorID is nil.
+func (r *ReplyRepository) RecentFingerprints(ctx context.Context, authorID *primitive.ObjectID, since time.Time, limit int64) ([]PostFingerprint, error) {
+	return recentFingerprints(ctx, config.DB.Collection(r.col), authorID, since, limit)
+}
+
+// Search returns one page of replies matching the filter, ranked by
+// relevance and recency. Replies are only found while their vent could be.
+func (r *ReplyRepository) Search(ctx context.Context, f SearchFilter, epoch time.Time, gravity time.Duration, after *Cursor, limit int64) (models.Page[models.SearchHit], error) {
+	match := f.created(bson.M{
+		"$text":          bson.M{"$search": f.Query},
+		"is_deleted":     false,
+		"under_review":   bson.M{"$ne": true},
+		"shadowed_until": bson.M{"$not": bson.M{"$gt": time.Now()}},
+	})
+	f.Exclude.excludeAuthors(match)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		rankStage(epoch, gravity),
+		{{Key: "$lookup", Value: bson.M{"from": "vents", "localField": "vent_id", "foreignField": "_id", "as": "vent"}}},
+		{{Key: "$unwind", Value: "$vent"}},
+		{{Key: "$match", Value: f.ventFilter("vent.")}},
+		{{Key: "$project", Value: bson.M{
+			"target_type": bson.M{"$literal": models.TargetReply},
+			"vent_id":     1,
+			"content":     1,
+			"tags":        "$vent.tags",
+			"kind":        "$vent.kind",
+			"rank":        1,
+			"created_at":  1,
+		}}},
+	}
+	return searchPage(ctx, config.DB.Collection(r.col), pipeline, after, limit)
+}
+
+// SoftDelete turns a reply into a tombstone.
+func (r *ReplyRepository) SoftDelete(ctx context.Context, id, by primitive.ObjectID, reason string) error {
+	now := time.Now()
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": false},
+		bson.M{"$set": bson.M{
+			"is_deleted":    true,
+			"deleted_by":    by,
+			"deleted_at":    now,
+			"delete_reason": reason,
+			"updated_at":    now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Restore undoes a soft delete.
+func (r *ReplyRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": true},
+		bson.M{
+			"$set":   bson.M{"is_deleted": false, "updated_at": time.Now()},
+			"$unset": bson.M{"deleted_by": "", "deleted_at": "", "delete_reason": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// IncrementReplyCount adjusts the number of direct replies to a reply.
+func (r *ReplyRepository) IncrementReplyCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"reply_count": delta}},
+	)
+	return err
+}
+
+// ApplyVoteDelta adjusts the reply's vote counters and score by the given deltas.
+func (r *ReplyRepository) ApplyVoteDelta(ctx context.Context, id primitive.ObjectID, up, down int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"upvotes": up, "downvotes": down, "score": up - down}},
+	)
+	return err
+}
+
+// SetVoteCounts overwrites the reply's vote counters, e.g. after a recount.
+func (r *ReplyRepository) SetVoteCounts(ctx context.Context, id primitive.ObjectID, up, down int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"upvotes": up, "downvotes": down, "score": up - down}},
+	)
+	return err
+}
+
+// SetAccepted marks or unmarks a reply as the accepted answer.
+func (r *ReplyRepository) SetAccepted(ctx context.Context, id primitive.ObjectID, accepted bool) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"accepted": accepted}},
+	)
+	return err
+}
+
+// AddReport counts one more distinct report of the given weight against a
+// reply. It returns the reply as it was before the update, so the caller can
+// tell whether this report crossed a threshold.
+func (r *ReplyRepository) AddReport(ctx context.Context, id primitive.ObjectID, weight float64) (*models.Reply, error) {
+	var rep models.Reply
+	err := config.DB.Collection(r.col).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"report_count": 1, "report_weight": weight}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&rep)
+	if err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// SetUnderReview hides a reply pending moderator review, or lifts the hold.
+// It returns mongo.ErrNoDocuments if the reply is already in that state.
+func (r *ReplyRepository) SetUnderReview(ctx context.Context, id primitive.ObjectID, underReview bool) error {
+	filter := bson.M{"_id": id, "under_review": true}
+	if underReview {
+		// content reported before review holds existed has no field
+		filter["under_review"] = bson.M{"$ne": true}
+	}
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"under_review": underReview}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ShadowByAuthor shadows every one of the author's replies until the given
+// time; nil makes them visible again.
+func (r *ReplyRepository) ShadowByAuthor(ctx context.Context, authorID primitive.ObjectID, until *time.Time) error {
+	update := bson.M{"$set": bson.M{"shadowed_until": until}}
+	if until == nil {
+		update = bson.M{"$unset": bson.M{"shadowed_until": ""}}
+	}
+	_, err := config.DB.Collection(r.col).UpdateMany(ctx, bson.M{"author_id": authorID}, update)
+	return err
+}
@@ -7,17 +7,230 @@ import (
 	"ventapp/server/ventapp/config"
 	"ventapp/server/ventapp/models"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ReportRepository struct{ col string }
 
 func NewReportRepository() *ReportRepository { return &ReportRepository{col: "reports"} }
 
+// EnsureIndexes allows one report per user per target and backs the queue,
+// which groups open reports by target. Legacy reports without a target are
+// left out of the unique index until BackfillLegacy gives them one.
+func (r *ReportRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "reporter", Value: 1}, {Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"target_type": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "target_id", Value: 1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	return err
+}
+
 func (r *ReportRepository) Create(ctx context.Context, rep *models.Report) error {
 	rep.ID = primitive.NewObjectID()
 	rep.CreatedAt = time.Now()
+	rep.Status = models.ReportOpen
 	rep.Resolved = false
 	_, err := config.DB.Collection(r.col).InsertOne(ctx, rep)
 	return err
 }
+
+// OpenSystem files or reopens the report the system keeps on a target, whose
+// Reporter is the nil id. Reopening a closed one puts it back in the queue
+// with the new reason and details.
+func (r *ReportRepository) OpenSystem(ctx context.Context, rep *models.Report) error {
+	rep.Reporter = primitive.NilObjectID
+	rep.CreatedAt = time.Now()
+	rep.Status = models.ReportOpen
+	rep.Resolved = false
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"reporter": rep.Reporter, "target_type": rep.TargetType, "target_id": rep.TargetID},
+		bson.M{
+			"$set": bson.M{
+				"vent_id":       rep.VentID,
+				"reason":        rep.Reason,
+				"details":       rep.Details,
+				"weight":        rep.Weight,
+				"priority":      rep.Priority,
+				"university_id": rep.UniversityID,
+				"department_id": rep.DepartmentID,
+				"course_id":     rep.CourseID,
+				"created_at":    rep.CreatedAt,
+				"status":        rep.Status,
+				"resolved":      rep.Resolved,
+			},
+			"$unset": bson.M{"resolved_by": "", "resolved_at": "", "outcome": "", "note": ""},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.UpsertedID.(primitive.ObjectID); ok {
+		rep.ID = id
+	}
+	return nil
+}
+
+// ReportFilter narrows the moderation queue. Empty fields are not filtered
+// on; Priority selects only the priority queue. Scopes limits the queue to
+// reports inside the given role bindings; nil means every scope.
+type ReportFilter struct {
+	Status     string
+	Reason     string
+	TargetType string
+	Priority   bool
+	Scopes     []models.RoleBinding
+}
+
+func (f ReportFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.Reason != "" {
+		filter["reason"] = f.Reason
+	}
+	if f.TargetType != "" {
+		filter["target_type"] = f.TargetType
+	}
+	if f.Priority {
+		filter["priority"] = true
+	}
+	restrictToScopes(filter, f.Scopes)
+	return filter
+}
+
+// priorityRank is added to the rank of groups holding a priority report, to
+// put them ahead of any number of ordinary reports.
+const priorityRank = 1 << 40
+
+// FindGroups returns one page of the queue: reports matching filter grouped
+// by target, priority groups first, then most reported, then most recently
+// reported.
+func (r *ReportRepository) FindGroups(ctx context.Context, f ReportFilter, after *Cursor, limit int64) (models.Page[models.ReportGroup], error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: f.bson()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               "$target_id",
+			"target_type":       bson.M{"$first": "$target_type"},
+			"vent_id":           bson.M{"$first": "$vent_id"},
+			"count":             bson.M{"$sum": 1},
+			"reasons":           bson.M{"$addToSet": "$reason"},
+			"priority":          bson.M{"$max": bson.M{"$ifNull": bson.A{"$priority", false}}},
+			"first_reported_at": bson.M{"$min": "$created_at"},
+			"created_at":        bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"rank": bson.M{"$add": bson.A{
+			"$count",
+			bson.M{"$cond": bson.A{"$priority", priorityRank, 0}},
+		}}}}},
+	}
+	p := PageRequest{SortField: "rank", After: after, Limit: limit}
+	return aggregatePage(ctx, config.DB.Collection(r.col), pipeline, p, func(g models.ReportGroup) Cursor {
+		rank := float64(g.Count)
+		if g.Priority {
+			rank += priorityRank
+		}
+		return Cursor{Value: rank, CreatedAt: g.LastReportedAt, ID: g.TargetID}
+	})
+}
+
+// FindByTarget returns every report on a target, newest first.
+func (r *ReportRepository) FindByTarget(ctx context.Context, targetType string, targetID primitive.ObjectID) ([]models.Report, error) {
+	opts := &options.FindOptions{}
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"target_type": targetType, "target_id": targetID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []models.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// CloseOpen marks every open report on a target as resolved or dismissed by
+// the moderator and returns how many were closed.
+func (r *ReportRepository) CloseOpen(ctx context.Context, targetType string, targetID primitive.ObjectID, status string, by primitive.ObjectID, outcome, note string) (int64, error) {
+	res, err := config.DB.Collection(r.col).UpdateMany(ctx,
+		bson.M{"target_type": targetType, "target_id": targetID, "status": models.ReportOpen},
+		bson.M{"$set": bson.M{
+			"status":      status,
+			"resolved":    true,
+			"resolved_by": by,
+			"resolved_at": time.Now(),
+			"outcome":     outcome,
+			"note":        note,
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// BackfillLegacy turns reports filed before reports had targets and statuses,
+// which name only a vent and whether they were resolved, into vent reports
+// carrying the vent's scope. Where a user reported a vent more than once only
+// the first report is kept.
+func (r *ReportRepository) BackfillLegacy(ctx context.Context) error {
+	col := config.DB.Collection(r.col)
+	legacy := bson.M{"target_type": bson.M{"$exists": false}}
+
+	cursor, err := col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: legacy}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"reporter": "$reporter", "vent": "$vent_id"}, "ids": bson.M{"$push": "$_id"}}}},
+		{{Key: "$match", Value: bson.M{"ids.1": bson.M{"$exists": true}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var dupes []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &dupes); err != nil {
+		return err
+	}
+	var extra []primitive.ObjectID
+	for _, d := range dupes {
+		extra = append(extra, d.IDs[1:]...)
+	}
+	if len(extra) > 0 {
+		if _, err := col.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": extra}}); err != nil {
+			return err
+		}
+	}
+
+	cursor, err = col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: legacy}},
+		{{Key: "$lookup", Value: bson.M{"from": "vents", "localField": "vent_id", "foreignField": "_id", "as": "vent"}}},
+		{{Key: "$set", Value: bson.M{"vent": bson.M{"$first": "$vent"}}}},
+		{{Key: "$project", Value: bson.M{
+			"target_type":   models.TargetVent,
+			"target_id":     "$vent_id",
+			"weight":        bson.M{"$ifNull": bson.A{"$weight", 0}},
+			"status":        bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$resolved", true}}, models.ReportResolved, models.ReportOpen}},
+			"university_id": "$vent.university_id",
+			"department_id": "$vent.department_id",
+			"course_id":     "$vent.course_id",
+		}}},
+		{{Key: "$merge", Value: bson.M{"into": r.col, "on": "_id", "whenMatched": "merge", "whenNotMatched": "discard"}}},
+	})
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
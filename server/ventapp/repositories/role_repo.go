@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepository struct {
+	col      string
+	auditCol string
+}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{col: "role_bindings", auditCol: "role_audit"}
+}
+
+// EnsureIndexes makes a user hold a given role in a given scope at most once.
+func (r *RoleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "role", Value: 1}, {Key: "scope_type", Value: 1}, {Key: "scope_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = config.DB.Collection(r.auditCol).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+func (r *RoleRepository) Create(ctx context.Context, b *models.RoleBinding) error {
+	b.ID = primitive.NewObjectID()
+	b.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, b)
+	return err
+}
+
+func (r *RoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.RoleBinding, error) {
+	var b models.RoleBinding
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// FindByUser returns every role binding held by the user.
+func (r *RoleRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.RoleBinding, error) {
+	return r.FindByUserIn(ctx, userID, nil)
+}
+
+// FindByUserIn returns the role bindings held by the user that are scoped
+// inside scopes; nil scopes returns them all.
+func (r *RoleRepository) FindByUserIn(ctx context.Context, userID primitive.ObjectID, scopes *BindingScopes) ([]models.RoleBinding, error) {
+	filter := bson.M{"user_id": userID}
+	restrictToBindingScopes(filter, scopes)
+	cursor, err := config.DB.Collection(r.col).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bindings []models.RoleBinding
+	if err := cursor.All(ctx, &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// FindByRoles returns every binding of any of the given roles.
+func (r *RoleRepository) FindByRoles(ctx context.Context, roles []models.Role) ([]models.RoleBinding, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"role": bson.M{"$in": roles}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bindings []models.RoleBinding
+	if err := cursor.All(ctx, &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *RoleRepository) CreateAudit(ctx context.Context, e *models.RoleAuditEntry) error {
+	e.ID = primitive.NewObjectID()
+	e.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.auditCol).InsertOne(ctx, e)
+	return err
+}
+
+// FindAudit returns the most recent audit entries, optionally restricted to
+// one user, for bindings scoped inside scopes; nil scopes returns entries
+// for every scope.
+func (r *RoleRepository) FindAudit(ctx context.Context, userID *primitive.ObjectID, scopes *BindingScopes, limit int64) ([]models.RoleAuditEntry, error) {
+	filter := bson.M{}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+	restrictToBindingScopes(filter, scopes)
+	opts := &options.FindOptions{}
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	opts.SetLimit(limit)
+
+	cursor, err := config.DB.Collection(r.auditCol).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.RoleAuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
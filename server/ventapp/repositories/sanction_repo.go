@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SanctionRepository struct{ col string }
+
+func NewSanctionRepository() *SanctionRepository { return &SanctionRepository{col: "sanctions"} }
+
+// EnsureIndexes backs the active-sanction lookup made on every authenticated
+// request.
+func (r *SanctionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "lifted_at", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *SanctionRepository) Create(ctx context.Context, s *models.Sanction) error {
+	s.ID = primitive.NewObjectID()
+	s.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, s)
+	return err
+}
+
+func (r *SanctionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Sanction, error) {
+	var s models.Sanction
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FindActive returns the user's sanctions that are in force at now.
+func (r *SanctionRepository) FindActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.Sanction, error) {
+	return r.find(ctx, bson.M{
+		"user_id":   userID,
+		"lifted_at": nil,
+		"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	})
+}
+
+// FindByUser returns every sanction ever issued against the user, newest first.
+func (r *SanctionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Sanction, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *SanctionRepository) find(ctx context.Context, filter bson.M) ([]models.Sanction, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sanctions := []models.Sanction{}
+	if err := cursor.All(ctx, &sanctions); err != nil {
+		return nil, err
+	}
+	return sanctions, nil
+}
+
+// Lift ends a sanction early. It returns mongo.ErrNoDocuments if the sanction
+// was already lifted.
+func (r *SanctionRepository) Lift(ctx context.Context, id, by primitive.ObjectID, reason string) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "lifted_at": nil},
+		bson.M{"$set": bson.M{"lifted_by": by, "lifted_at": time.Now(), "lift_reason": reason}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
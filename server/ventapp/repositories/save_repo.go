@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SaveRepository struct{ col string }
+
+func NewSaveRepository() *SaveRepository { return &SaveRepository{col: "saves"} }
+
+// EnsureIndexes allows one save per user per vent and backs the saved list,
+// which pages newest first, optionally within a folder.
+func (r *SaveRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "vent_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "folder", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+// Upsert saves the vent for the user, moving it to folder if it was already
+// saved. It reports whether the save is new.
+func (r *SaveRepository) Upsert(ctx context.Context, userID, ventID primitive.ObjectID, folder string) (bool, error) {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "vent_id": ventID},
+		bson.M{
+			"$set":         bson.M{"folder": folder},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent save of the same vent inserted it first
+		_, err = config.DB.Collection(r.col).UpdateOne(ctx,
+			bson.M{"user_id": userID, "vent_id": ventID},
+			bson.M{"$set": bson.M{"folder": folder}},
+		)
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// Delete removes the user's save of the vent and reports whether one existed.
+func (r *SaveRepository) Delete(ctx context.Context, userID, ventID primitive.ObjectID) (bool, error) {
+	res, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"user_id": userID, "vent_id": ventID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// Exists reports whether the user has saved the vent.
+func (r *SaveRepository) Exists(ctx context.Context, userID, ventID primitive.ObjectID) (bool, error) {
+	n, err := config.DB.Collection(r.col).CountDocuments(ctx, bson.M{"user_id": userID, "vent_id": ventID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// FindPage returns one page of the user's saves, newest first. A nil folder
+// returns saves from every folder.
+func (r *SaveRepository) FindPage(ctx context.Context, userID primitive.ObjectID, folder *string, after *Cursor, limit int64) (models.Page[models.Save], error) {
+	filter := bson.M{"user_id": userID}
+	if folder != nil {
+		filter["folder"] = *folder
+	}
+	return findPage(ctx, config.DB.Collection(r.col), filter, PageRequest{After: after, Limit: limit}, func(s models.Save) Cursor {
+		return Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+	})
+}
+
+// Folders lists the user's folders with the number of saves in each.
+func (r *SaveRepository) Folders(ctx context.Context, userID primitive.ObjectID) ([]models.SaveFolder, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$folder", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	folders := []models.SaveFolder{}
+	if err := cursor.All(ctx, &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// ImportSavedBy moves the saves recorded in the saved_by arrays vents used to
+// carry into the saves collection, unfiled and dated by the vent, recounts
+// those vents' save_count and drops the arrays.
+func (r *SaveRepository) ImportSavedBy(ctx context.Context) error {
+	vents := config.DB.Collection("vents")
+	legacy := bson.M{"saved_by.0": bson.M{"$exists": true}}
+	ids, err := distinctIDs(ctx, vents, "_id", legacy)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	cursor, err := vents.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: legacy}},
+		{{Key: "$unwind", Value: "$saved_by"}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"user_id":    "$saved_by",
+			"vent_id":    "$_id",
+			"folder":     "",
+			"created_at": "$created_at",
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           r.col,
+			"on":             bson.A{"user_id", "vent_id"},
+			"whenMatched":    "keepExisting",
+			"whenNotMatched": "insert",
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return err
+	}
+
+	cursor, err = config.DB.Collection(r.col).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"vent_id": bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$vent_id", "save_count": bson.M{"$sum": 1}}}},
+		{{Key: "$merge", Value: bson.M{"into": "vents", "on": "_id", "whenMatched": "merge", "whenNotMatched": "discard"}}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return err
+	}
+	_, err = vents.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"saved_by": ""}})
+	return err
+}
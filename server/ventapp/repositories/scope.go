@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// restrictToScopes limits filter to documents carrying scope ids inside one of
+// the given role bindings. Nil scopes leave filter unrestricted; an empty
+// slice matches nothing.
+func restrictToScopes(filter bson.M, scopes []models.RoleBinding) {
+	if scopes == nil {
+		return
+	}
+	or := bson.A{}
+	for _, b := range scopes {
+		switch b.ScopeType {
+		case models.ScopeGlobal:
+			return
+		case models.ScopeUniversity:
+			or = append(or, bson.M{"university_id": b.ScopeID})
+		case models.ScopeDepartment:
+			or = append(or, bson.M{"department_id": b.ScopeID})
+		case models.ScopeCourse:
+			or = append(or, bson.M{"course_id": b.ScopeID})
+		}
+	}
+	if len(or) == 0 {
+		// no usable scope: match nothing
+		or = append(or, bson.M{"_id": primitive.NilObjectID})
+	}
+	filter["$or"] = or
+}
+
+// BindingScopes is every university, department and course some role
+// bindings reach, including the departments and courses below a bound
+// university or department.
+type BindingScopes struct {
+	UniversityIDs []primitive.ObjectID
+	DepartmentIDs []primitive.ObjectID
+	CourseIDs     []primitive.ObjectID
+}
+
+// restrictToBindingScopes limits filter to role bindings or role audit
+// entries scoped inside s. A nil s leaves filter unrestricted.
+func restrictToBindingScopes(filter bson.M, s *BindingScopes) {
+	if s == nil {
+		return
+	}
+	in := func(ids []primitive.ObjectID) bson.M {
+		if ids == nil {
+			ids = []primitive.ObjectID{}
+		}
+		return bson.M{"$in": ids}
+	}
+	filter["$or"] = bson.A{
+		bson.M{"scope_type": models.ScopeUniversity, "scope_id": in(s.UniversityIDs)},
+		bson.M{"scope_type": models.ScopeDepartment, "scope_id": in(s.DepartmentIDs)},
+		bson.M{"scope_type": models.ScopeCourse, "scope_id": in(s.CourseIDs)},
+	}
+}
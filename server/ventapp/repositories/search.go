@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchIndex is the text index vents and replies are searched with. No
+// language is set, so words are not stemmed and every script is indexed
+// the same way.
+func searchIndex(fields bson.D, weights bson.M) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    fields,
+		Options: options.Index().SetName("search").SetWeights(weights).SetDefaultLanguage("none"),
+	}
+}
+
+// SearchFilter narrows a search. Query uses MongoDB text search syntax, so
+// quoted phrases and -excluded words work. Nil and empty fields are not
+// filtered on; the scope, kind and tag filters apply to the vent a hit
+// belongs to. Exclude leaves out what the searcher blocked, muted or hid,
+// along with replies on those vents.
+type SearchFilter struct {
+	Query        string
+	Tag          string
+	UniversityID *primitive.ObjectID
+	DepartmentID *primitive.ObjectID
+	CourseID     *primitive.ObjectID
+	Kind         string
+	From         *time.Time
+	To           *time.Time
+	Exclude      Exclusions
+}
+
+// ventFilter returns the conditions on a vent for it or its replies to be
+// found, with field names under prefix. Deleted, held and shadowed vents are
+// never found, whoever is searching.
+func (f SearchFilter) ventFilter(prefix string) bson.M {
+	filter := bson.M{
+		prefix + "is_deleted":     false,
+		prefix + "under_review":   bson.M{"$ne": true},
+		prefix + "shadowed_until": bson.M{"$not": bson.M{"$gt": time.Now()}},
+	}
+	f.Exclude.excludeVents(filter, prefix)
+	if f.Tag != "" {
+		filter[prefix+"tags"] = f.Tag
+	}
+	if f.UniversityID != nil {
+		filter[prefix+"university_id"] = *f.UniversityID
+	}
+	if f.DepartmentID != nil {
+		filter[prefix+"department_id"] = *f.DepartmentID
+	}
+	if f.CourseID != nil {
+		filter[prefix+"course_id"] = *f.CourseID
+	}
+	switch f.Kind {
+	case "":
+	case models.KindVent:
+		filter[prefix+"kind"] = bson.M{"$in": bson.A{models.KindVent, nil}}
+	default:
+		filter[prefix+"kind"] = f.Kind
+	}
+	return filter
+}
+
+// created restricts filter to the date range.
+func (f SearchFilter) created(filter bson.M) bson.M {
+	if f.From == nil && f.To == nil {
+		return filter
+	}
+	r := bson.M{}
+	if f.From != nil {
+		r["$gte"] = *f.From
+	}
+	if f.To != nil {
+		r["$lt"] = *f.To
+	}
+	filter["created_at"] = r
+	return filter
+}
+
+// rankStage adds a rank combining text relevance with recency: the log of
+// the text score plus age measured from epoch in units of gravity. Like the
+// hot score it does not change as time passes, so it can be paged on.
+func rankStage(epoch time.Time, gravity time.Duration) bson.D {
+	return bson.D{{Key: "$addFields", Value: bson.M{
+		"rank": bson.M{"$add": bson.A{
+			bson.M{"$ln": bson.M{"$meta": "textScore"}},
+			bson.M{"$divide": bson.A{
+				bson.M{"$subtract": bson.A{"$created_at", epoch}},
+				float64(gravity / time.Millisecond),
+			}},
+		}},
+	}}}
+}
+
+// searchPage runs a search pipeline and pages its hits by rank.
+func searchPage(ctx context.Context, col *mongo.Collection, pipeline mongo.Pipeline, after *Cursor, limit int64) (models.Page[models.SearchHit], error) {
+	p := PageRequest{SortField: "rank", After: after, Limit: limit}
+	return aggregatePage(ctx, col, pipeline, p, func(h models.SearchHit) Cursor {
+		return Cursor{Value: h.Rank, CreatedAt: h.CreatedAt, ID: h.ID}
+	})
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TagFollowRepository struct{ col string }
+
+func NewTagFollowRepository() *TagFollowRepository {
+	return &TagFollowRepository{col: "tag_follows"}
+}
+
+// EnsureIndexes allows one follow per user per tag and backs the lookup of a
+// tag's followers when tags are merged or banned.
+func (r *TagFollowRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "tag", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tag", Value: 1}}},
+	})
+	return err
+}
+
+// Upsert makes the user follow the tag and reports whether the follow is new.
+func (r *TagFollowRepository) Upsert(ctx context.Context, userID primitive.ObjectID, tag string) (bool, error) {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"user_id": userID, "tag": tag},
+		bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// Delete removes the user's follow of the tag and reports whether one existed.
+func (r *TagFollowRepository) Delete(ctx context.Context, userID primitive.ObjectID, tag string) (bool, error) {
+	res, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"user_id": userID, "tag": tag})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// FindByUser returns the tags the user follows, in the order followed.
+func (r *TagFollowRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.TagFollow, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	follows := []models.TagFollow{}
+	if err := cursor.All(ctx, &follows); err != nil {
+		return nil, err
+	}
+	return follows, nil
+}
+
+// Move transfers a tag's followers to another tag, keeping a single follow
+// for users who already followed both. It returns how many users follow
+// the other tag afterwards.
+func (r *TagFollowRepository) Move(ctx context.Context, from, into string) (int, error) {
+	col := config.DB.Collection(r.col)
+	cursor, err := col.Find(ctx, bson.M{"tag": from})
+	if err != nil {
+		return 0, err
+	}
+	var follows []models.TagFollow
+	if err := cursor.All(ctx, &follows); err != nil {
+		return 0, err
+	}
+
+	if len(follows) > 0 {
+		writes := make([]mongo.WriteModel, 0, len(follows))
+		for _, f := range follows {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"user_id": f.UserID, "tag": into}).
+				SetUpdate(bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": f.CreatedAt}}).
+				SetUpsert(true))
+		}
+		if _, err := col.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return 0, err
+		}
+	}
+	if err := r.DeleteByTag(ctx, from); err != nil {
+		return 0, err
+	}
+	n, err := col.CountDocuments(ctx, bson.M{"tag": into})
+	return int(n), err
+}
+
+// DeleteByTag removes every follow of a tag.
+func (r *TagFollowRepository) DeleteByTag(ctx context.Context, tag string) error {
+	_, err := config.DB.Collection(r.col).DeleteMany(ctx, bson.M{"tag": tag})
+	return err
+}
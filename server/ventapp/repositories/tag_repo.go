@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TagRepository struct{ col string }
+
+func NewTagRepository() *TagRepository { return &TagRepository{col: "tags"} }
+
+// TagStatus selects which part of the tag directory is listed.
+type TagStatus string
+
+const (
+	TagsActive TagStatus = ""
+	TagsBanned TagStatus = "banned"
+	TagsMerged TagStatus = "merged"
+)
+
+// TagFilter narrows the tag directory. Prefix matches the start of a name,
+// for autocomplete.
+type TagFilter struct {
+	Prefix string
+	Status TagStatus
+}
+
+func (f TagFilter) bson() bson.M {
+	filter := bson.M{}
+	switch f.Status {
+	case TagsBanned:
+		filter["banned"] = true
+	case TagsMerged:
+		filter["merged_into"] = bson.M{"$exists": true}
+	default:
+		filter["banned"] = false
+		filter["merged_into"] = bson.M{"$exists": false}
+	}
+	if f.Prefix != "" {
+		filter["name"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(f.Prefix)}
+	}
+	return filter
+}
+
+// EnsureIndexes keeps tag names unique, which also serves prefix lookups,
+// and backs the directory, which lists the most used tags first.
+func (r *TagRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "vent_count", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+func (r *TagRepository) FindByName(ctx context.Context, name string) (*models.Tag, error) {
+	var t models.Tag
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"name": name}).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// FindByNames returns the directory entries among names, keyed by name.
+func (r *TagRepository) FindByNames(ctx context.Context, names []string) (map[string]models.Tag, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tags []models.Tag
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.Tag, len(tags))
+	for _, t := range tags {
+		byName[t.Name] = t
+	}
+	return byName, nil
+}
+
+// FindPage returns one page of the directory, most used tags first.
+func (r *TagRepository) FindPage(ctx context.Context, f TagFilter, after *Cursor, limit int64) (models.Page[models.Tag], error) {
+	p := PageRequest{SortField: "vent_count", After: after, Limit: limit}
+	return findPage(ctx, config.DB.Collection(r.col), f.bson(), p, func(t models.Tag) Cursor {
+		return Cursor{Value: float64(t.VentCount), CreatedAt: t.CreatedAt, ID: t.ID}
+	})
+}
+
+// upsertTag builds an update applying set and inc to a tag, adding the tag
+// to the directory first if it is new. Either may be nil.
+func upsertTag(set, inc bson.M) bson.M {
+	now := time.Now()
+	if set == nil {
+		set = bson.M{}
+	}
+	set["updated_at"] = now
+	insert := bson.M{"_id": primitive.NewObjectID(), "created_at": now}
+	defaults := bson.M{"description": "", "banned": false, "vent_count": 0, "follower_count": 0}
+	for field, v := range defaults {
+		_, inSet := set[field]
+		_, inInc := inc[field]
+		if !inSet && !inInc {
+			insert[field] = v
+		}
+	}
+	update := bson.M{"$set": set, "$setOnInsert": insert}
+	if inc != nil {
+		update["$inc"] = inc
+	}
+	return update
+}
+
+// IncrementVentCounts adjusts how many vents carry each of the tags. Tags
+// that gain a vent are added to the directory if they are new.
+func (r *TagRepository) IncrementVentCounts(ctx context.Context, names []string, delta int) error {
+	if len(names) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(names))
+	for _, name := range names {
+		m := mongo.NewUpdateOneModel().SetFilter(bson.M{"name": name})
+		if delta > 0 {
+			m.SetUpdate(upsertTag(nil, bson.M{"vent_count": delta})).SetUpsert(true)
+		} else {
+			m.SetUpdate(bson.M{"$inc": bson.M{"vent_count": delta}})
+		}
+		writes = append(writes, m)
+	}
+	_, err := config.DB.Collection(r.col).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// SetVentCounts overwrites the vent counts of the given tags, adding them to
+// the directory if they are new, and zeroes every other tag.
+func (r *TagRepository) SetVentCounts(ctx context.Context, counts map[string]int) error {
+	col := config.DB.Collection(r.col)
+	names := make([]string, 0, len(counts))
+	writes := make([]mongo.WriteModel, 0, len(counts))
+	for name, n := range counts {
+		names = append(names, name)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"name": name}).
+			SetUpdate(upsertTag(bson.M{"vent_count": n}, nil)).
+			SetUpsert(true))
+	}
+	if len(writes) > 0 {
+		if _, err := col.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	_, err := col.UpdateMany(ctx,
+		bson.M{"name": bson.M{"$nin": names}, "vent_count": bson.M{"$ne": 0}},
+		bson.M{"$set": bson.M{"vent_count": 0, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// IncrementFollowerCount adjusts how many users follow a tag.
+func (r *TagRepository) IncrementFollowerCount(ctx context.Context, name string, delta int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"name": name},
+		bson.M{"$inc": bson.M{"follower_count": delta}},
+	)
+	return err
+}
+
+// SetCounts overwrites a tag's counters, e.g. after a merge, adding the tag
+// to the directory if needed.
+func (r *TagRepository) SetCounts(ctx context.Context, name string, vents, followers int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"name": name},
+		upsertTag(bson.M{"vent_count": vents, "follower_count": followers}, nil),
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SetDescription describes a tag, adding it to the directory if needed.
+func (r *TagRepository) SetDescription(ctx context.Context, name, description string, by primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"name": name},
+		upsertTag(bson.M{"description": description, "updated_by": by}, nil),
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SetBanned bans or unbans a tag, adding it to the directory if needed so
+// that tags can be banned before anyone uses them.
+func (r *TagRepository) SetBanned(ctx context.Context, name string, banned bool, by primitive.ObjectID) error {
+	set := bson.M{"banned": banned, "updated_by": by}
+	if banned {
+		set["vent_count"] = 0
+		set["follower_count"] = 0
+	}
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx, bson.M{"name": name}, upsertTag(set, nil), options.Update().SetUpsert(true))
+	return err
+}
+
+// MarkMerged records that a tag is now stored as another, adding it to the
+// directory if needed. Tags already merged into it are redirected too, so
+// no tag is ever more than one merge away from the tag it is stored as.
+func (r *TagRepository) MarkMerged(ctx context.Context, name, into string, by primitive.ObjectID) error {
+	col := config.DB.Collection(r.col)
+	_, err := col.UpdateOne(ctx,
+		bson.M{"name": name},
+		upsertTag(bson.M{"merged_into": into, "vent_count": 0, "follower_count": 0, "updated_by": by}, nil),
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = col.UpdateMany(ctx,
+		bson.M{"merged_into": name},
+		bson.M{"$set": bson.M{"merged_into": into, "updated_at": time.Now()}},
+	)
+	return err
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UniversityRepository struct{ col string }
+
+func NewUniversityRepository() *UniversityRepository {
+	return &UniversityRepository{col: "universities"}
+}
+
+func (r *UniversityRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.University, error) {
+	var u models.University
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
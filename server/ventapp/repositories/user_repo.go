@@ -9,6 +9,8 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -36,3 +38,155 @@ func (r *UserRepository) FindByTelegramID(ctx context.Context, tgID int64) (*mod
 	}
 	return &u, nil
 }
+
+func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var u models.User
+	if err := config.DB.Collection(r.colCollectionName).FindOne(ctx, bson.M{"_id": id}).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// FindAdminIDs returns the ids of users with the legacy IsAdmin flag.
+func (r *UserRepository) FindAdminIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	cursor, err := config.DB.Collection(r.colCollectionName).Find(ctx,
+		bson.M{"is_admin": true},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids, nil
+}
+
+// SetCourses replaces the courses the user is enrolled in.
+func (r *UserRepository) SetCourses(ctx context.Context, id primitive.ObjectID, courseIDs []primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.colCollectionName).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"course_ids": courseIDs}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetAcademic sets the university and department the user studies at,
+// clearing either when nil.
+func (r *UserRepository) SetAcademic(ctx context.Context, id primitive.ObjectID, universityID, departmentID *primitive.ObjectID) error {
+	set, unset := bson.M{}, bson.M{}
+	for field, v := range map[string]*primitive.ObjectID{"university_id": universityID, "department_id": departmentID} {
+		if v != nil {
+			set[field] = *v
+		} else {
+			unset[field] = ""
+		}
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := config.DB.Collection(r.colCollectionName).UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// BackfillAcademic gives users without a university or department the ones
+// they most likely study at: the department most of their courses belong to,
+// or else the university and department they post to most. Fields already
+// set are kept.
+func (r *UserRepository) BackfillAcademic(ctx context.Context) error {
+	keepSet := bson.A{bson.M{"$set": bson.M{
+		"university_id": bson.M{"$ifNull": bson.A{"$university_id", "$$new.university_id"}},
+		"department_id": bson.M{"$ifNull": bson.A{"$department_id", "$$new.department_id"}},
+	}}}
+	merge := bson.D{{Key: "$merge", Value: bson.M{"into": r.colCollectionName, "on": "_id", "whenMatched": keepSet, "whenNotMatched": "discard"}}}
+	// the most common value per user of the grouped key, by how often it occurs
+	mostCommon := func(key bson.M) []bson.D {
+		return []bson.D{
+			{{Key: "$group", Value: bson.M{"_id": key, "n": bson.M{"$sum": 1}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "n", Value: -1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":           "$_id.user",
+				"university_id": bson.M{"$first": "$_id.university"},
+				"department_id": bson.M{"$first": "$_id.department"},
+			}}},
+		}
+	}
+
+	fromCourses := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"department_id": bson.M{"$exists": false}, "course_ids.0": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$course_ids"}},
+		{{Key: "$lookup", Value: bson.M{"from": "courses", "localField": "course_ids", "foreignField": "_id", "as": "course"}}},
+		{{Key: "$unwind", Value: "$course"}},
+		{{Key: "$lookup", Value: bson.M{"from": "departments", "localField": "course.department_id", "foreignField": "_id", "as": "department"}}},
+		{{Key: "$unwind", Value: "$department"}},
+	}
+	fromCourses = append(fromCourses, mostCommon(bson.M{"user": "$_id", "university": "$department.university_id", "department": "$department._id"})...)
+	fromCourses = append(fromCourses, merge)
+	cursor, err := config.DB.Collection(r.colCollectionName).Aggregate(ctx, fromCourses)
+	if err != nil {
+		return err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return err
+	}
+
+	fromVents := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_deleted": false, "university_id": bson.M{"$ne": nil}}}},
+	}
+	fromVents = append(fromVents, mostCommon(bson.M{"user": "$author_id", "university": "$university_id", "department": "$department_id"})...)
+	fromVents = append(fromVents, merge)
+	cursor, err = config.DB.Collection("vents").Aggregate(ctx, fromVents)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// FindByUsernames returns the users with any of the usernames.
+func (r *UserRepository) FindByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	cursor, err := config.DB.Collection(r.colCollectionName).Find(ctx, bson.M{"username": bson.M{"$in": usernames}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetBotBlocked records whether the user with the Telegram account has
+// the bot blocked.
+func (r *UserRepository) SetBotBlocked(ctx context.Context, telegramID int64, blocked bool) error {
+	update := bson.M{"$unset": bson.M{"bot_blocked": ""}}
+	if blocked {
+		update = bson.M{"$set": bson.M{"bot_blocked": true}}
+	}
+	_, err := config.DB.Collection(r.colCollectionName).UpdateOne(ctx, bson.M{"telegram_id": telegramID}, update)
+	return err
+}
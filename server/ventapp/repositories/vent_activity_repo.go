@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type VentActivityRepository struct{ col string }
+
+func NewVentActivityRepository() *VentActivityRepository {
+	return &VentActivityRepository{col: "vent_activity"}
+}
+
+func (r *VentActivityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "vent_id", Value: 1}, {Key: "bucket", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "bucket", Value: 1}}},
+	})
+	return err
+}
+
+// Increment adds delta net votes to the vent's counter for the given bucket.
+func (r *VentActivityRepository) Increment(ctx context.Context, ventID primitive.ObjectID, bucket time.Time, delta int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"vent_id": ventID, "bucket": bucket},
+		bson.M{"$inc": bson.M{"votes": delta}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SumSince returns net votes per vent across all buckets starting at or after since.
+func (r *VentActivityRepository) SumSince(ctx context.Context, since time.Time) (map[primitive.ObjectID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"bucket": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$vent_id", "votes": bson.M{"$sum": "$votes"}}}},
+	}
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		VentID primitive.ObjectID `bson:"_id"`
+		Votes  int                `bson:"votes"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	sums := make(map[primitive.ObjectID]int, len(rows))
+	for _, row := range rows {
+		sums[row.VentID] = row.Votes
+	}
+	return sums, nil
+}
+
+func (r *VentActivityRepository) DeleteBefore(ctx context.Context, t time.Time) error {
+	_, err := config.DB.Collection(r.col).DeleteMany(ctx, bson.M{"bucket": bson.M{"$lt": t}})
+	return err
+}
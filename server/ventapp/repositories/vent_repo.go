@@ -9,6 +9,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -18,6 +19,93 @@ type VentRepository struct {
 
 func NewVentRepository() *VentRepository { return &VentRepository{col: "vents"} }
 
+// VentFilter narrows feed queries. Nil fields are not filtered on.
+type VentFilter struct {
+	UniversityID *primitive.ObjectID
+	DepartmentID *primitive.ObjectID
+	CourseID     *primitive.ObjectID
+	Kind         string
+	// Tags keeps vents carrying any of the tags. An empty, non-nil slice
+	// matches nothing.
+	Tags []string
+	// Unanswered keeps only questions without an accepted answer.
+	Unanswered bool
+	// ViewerID is the user reading the feed, if any; vents by shadow-banned
+	// authors and vents held for review are only shown to the author.
+	ViewerID *primitive.ObjectID
+	// Exclude leaves out what the viewer blocked, muted or hid.
+	Exclude Exclusions
+}
+
+func (f VentFilter) bson() bson.M {
+	// vents held for review are only listed for their author; others see
+	// them on their own page
+	notHeld := bson.M{"under_review": bson.M{"$ne": true}}
+	filter := bson.M{"is_deleted": false, "$and": bson.A{notHeld}}
+	if f.ViewerID != nil {
+		filter["$and"] = bson.A{bson.M{"$or": bson.A{notHeld, bson.M{"author_id": *f.ViewerID}}}}
+	}
+	hideShadowed(filter, f.ViewerID)
+	f.Exclude.excludeVents(filter, "")
+	if f.UniversityID != nil {
+		filter["university_id"] = *f.UniversityID
+	}
+	if f.DepartmentID != nil {
+		filter["department_id"] = *f.DepartmentID
+	}
+	if f.CourseID != nil {
+		filter["course_id"] = *f.CourseID
+	}
+	if f.Tags != nil {
+		filter["tags"] = bson.M{"$in": f.Tags}
+	}
+	switch f.Kind {
+	case "":
+	case models.KindVent:
+		// vents posted before kinds existed have no kind field
+		filter["kind"] = bson.M{"$in": bson.A{models.KindVent, nil}}
+	default:
+		filter["kind"] = f.Kind
+	}
+	if f.Unanswered {
+		filter["kind"] = models.KindQuestion
+		filter["accepted_reply_id"] = nil
+	}
+	return filter
+}
+
+// hideShadowed restricts filter to content whose author is not shadow-banned,
+// plus the viewer's own content. viewerID is nil for anonymous viewers.
+func hideShadowed(filter bson.M, viewerID *primitive.ObjectID) {
+	notShadowed := bson.M{"$not": bson.M{"$gt": time.Now()}}
+	if viewerID == nil {
+		filter["shadowed_until"] = notShadowed
+		return
+	}
+	filter["$or"] = bson.A{bson.M{"shadowed_until": notShadowed}, bson.M{"author_id": *viewerID}}
+}
+
+// EnsureIndexes backs the feed queries, which filter by scope and page by
+// (sort key, created_at, _id), the lookup of an author's recent vents, tag
+// feeds and rewrites, and search over content and tags.
+func (r *VentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "reply_count", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "hot_score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "is_deleted", Value: 1}, {Key: "trending_score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "university_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "department_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "department_id", Value: 1}, {Key: "accepted_reply_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		searchIndex(bson.D{{Key: "content", Value: "text"}, {Key: "tags", Value: "text"}}, bson.M{"content": 1, "tags": 3}),
+	})
+	return err
+}
+
 func (r *VentRepository) Create(ctx context.Context, v *models.Vent) error {
 	v.ID = primitive.NewObjectID()
 	now := time.Now()
@@ -35,13 +123,76 @@ func (r *VentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	return &v, nil
 }
 
-// FindRecent returns the most recent vents up to limit
-func (r *VentRepository) FindRecent(ctx context.Context, limit int64) ([]models.Vent, error) {
-	opts := &options.FindOptions{}
-	opts.SetSort(bson.D{{Key: "created_at", Value: -1}})
-	opts.SetLimit(limit)
+// VentSort selects the ordering of a vent feed.
+type VentSort string
+
+const (
+	SortNewest    VentSort = "newest"
+	SortTop       VentSort = "top"
+	SortDiscussed VentSort = "most_discussed"
+	SortHot       VentSort = "hot"
+	SortTrending  VentSort = "trending"
+)
 
-	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"is_deleted": false}, opts)
+// ParseVentSort maps a sort query parameter to a VentSort, defaulting to newest.
+func ParseVentSort(s string) (VentSort, bool) {
+	switch s {
+	case "", "new", string(SortNewest):
+		return SortNewest, true
+	case string(SortTop):
+		return SortTop, true
+	case string(SortDiscussed):
+		return SortDiscussed, true
+	case string(SortHot):
+		return SortHot, true
+	case string(SortTrending):
+		return SortTrending, true
+	}
+	return "", false
+}
+
+// field is the vent field the sort ranks by before falling back to recency.
+func (s VentSort) field() string {
+	switch s {
+	case SortTop:
+		return "score"
+	case SortDiscussed:
+		return "reply_count"
+	case SortHot:
+		return "hot_score"
+	case SortTrending:
+		return "trending_score"
+	}
+	return ""
+}
+
+func (s VentSort) cursor(v models.Vent) Cursor {
+	c := Cursor{CreatedAt: v.CreatedAt, ID: v.ID}
+	switch s {
+	case SortTop:
+		c.Value = float64(v.Score)
+	case SortDiscussed:
+		c.Value = float64(v.ReplyCount)
+	case SortHot:
+		c.Value = v.HotScore
+	case SortTrending:
+		c.Value = v.TrendingScore
+	}
+	return c
+}
+
+// FindAnyByID returns a vent whether or not it has been deleted, for moderators.
+func (r *VentRepository) FindAnyByID(ctx context.Context, id primitive.ObjectID) (*models.Vent, error) {
+	var v models.Vent
+	if err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"_id": id}).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindByIDs returns the live vents among ids, keyed by id.
+func (r *VentRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Vent, error) {
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "is_deleted": false})
 	if err != nil {
 		return nil, err
 	}
@@ -51,5 +202,466 @@ func (r *VentRepository) FindRecent(ctx context.Context, limit int64) ([]models.
 	if err := cursor.All(ctx, &vents); err != nil {
 		return nil, err
 	}
-	return vents, nil
+	byID := make(map[primitive.ObjectID]models.Vent, len(vents))
+	for _, v := range vents {
+		byID[v.ID] = v
+	}
+	return byID, nil
+}
+
+// FindPage returns one page of vents matching filter in the given order. The
+// sort fields are backfilled by migration, so every sort is index-backed.
+func (r *VentRepository) FindPage(ctx context.Context, f VentFilter, sort VentSort, after *Cursor, limit int64) (models.Page[models.Vent], error) {
+	p := PageRequest{Sort: string(sort), SortField: sort.field(), After: after, Limit: limit}
+	return findPage(ctx, config.DB.Collection(r.col), f.bson(), p, sort.cursor)
+}
+
+// BackfillSortFields sets the score and live reply count of vents that lack
+// them.
+func (r *VentRepository) BackfillSortFields(ctx context.Context) error {
+	col := config.DB.Collection(r.col)
+	_, err := col.UpdateMany(ctx, bson.M{"score": bson.M{"$exists": false}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"score": bson.M{"$subtract": bson.A{
+			bson.M{"$ifNull": bson.A{"$upvotes", 0}},
+			bson.M{"$ifNull": bson.A{"$downvotes", 0}},
+		}}}}},
+	})
+	if err != nil {
+		return err
+	}
+	cursor, err := col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"reply_count": bson.M{"$exists": false}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":     "replies",
+			"let":      bson.M{"vent": "$_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$vent_id", "$$vent"}}, "is_deleted": false}}},
+			"as":       "live_replies",
+		}}},
+		{{Key: "$project", Value: bson.M{"reply_count": bson.M{"$size": "$live_replies"}}}},
+		{{Key: "$merge", Value: bson.M{"into": r.col, "on": "_id", "whenMatched": "merge", "whenNotMatched": "discard"}}},
+	})
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// FeedQuery selects and weights the vents of a reader's home feed. Vents
+// matching Tags, CourseIDs, DepartmentID or UniversityID get the matching
+// boosts from Weights on top of their hot score. Vents the reader has seen
+// since SeenSince are left out.
+type FeedQuery struct {
+	ViewerID     primitive.ObjectID
+	Since        time.Time
+	SeenSince    time.Time
+	Exclude      Exclusions
+	Tags         []string
+	UniversityID *primitive.ObjectID
+	DepartmentID *primitive.ObjectID
+	CourseIDs    []primitive.ObjectID
+	Weights      config.FeedWeights
+}
+
+// RankFeed returns the ids of up to limit vents for a home feed, best first.
+// The reader's own vents, those in Exclude and those they have seen are left
+// out; seen vents are looked up per candidate rather than listed, as a reader
+// can have seen thousands.
+func (r *VentRepository) RankFeed(ctx context.Context, q FeedQuery, limit int64) ([]primitive.ObjectID, error) {
+	match := VentFilter{ViewerID: &q.ViewerID, Exclude: q.Exclude}.bson()
+	match["created_at"] = bson.M{"$gte": q.Since}
+	match["author_id"] = bson.M{"$ne": q.ViewerID}
+
+	score := bson.A{bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$hot_score", 0}}, q.Weights.Global}}}
+	boost := func(w float64, cond bson.M) {
+		if w != 0 {
+			score = append(score, bson.M{"$cond": bson.A{cond, w, 0}})
+		}
+	}
+	if len(q.Tags) > 0 {
+		boost(q.Weights.Tag, bson.M{"$gt": bson.A{
+			bson.M{"$size": bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}, q.Tags}}},
+			0,
+		}})
+	}
+	if len(q.CourseIDs) > 0 {
+		boost(q.Weights.Course, bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$course_id", nil}}, q.CourseIDs}})
+	}
+	if q.DepartmentID != nil {
+		boost(q.Weights.Department, bson.M{"$eq": bson.A{"$department_id", *q.DepartmentID}})
+	}
+	if q.UniversityID != nil {
+		boost(q.Weights.University, bson.M{"$eq": bson.A{"$university_id", *q.UniversityID}})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "seen_vents",
+			"let":  bson.M{"vent": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"user_id": q.ViewerID,
+					"seen_at": bson.M{"$gte": q.SeenSince},
+					"$expr":   bson.M{"$eq": bson.A{"$vent_id", "$$vent"}},
+				}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 1}},
+			},
+			"as": "seen",
+		}}},
+		{{Key: "$match", Value: bson.M{"seen": bson.M{"$size": 0}}}},
+		{{Key: "$addFields", Value: bson.M{"feed_score": bson.M{"$add": score}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "feed_score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids, nil
+}
+
+// hotScoreUpdate sets hot_score Reddit-style: the order of magnitude of the
+// vent's net votes (replies count as replyWeight votes each) plus its age
+// since epoch over gravity seconds. Because age only enters as creation time,
+// the score only changes when votes or replies do and can be stored and
+// indexed. This is the one definition of the hot score.
+func hotScoreUpdate(epoch time.Time, gravity, replyWeight float64) mongo.Pipeline {
+	count := func(field string) bson.M { return bson.M{"$ifNull": bson.A{"$" + field, 0}} }
+	net := bson.M{"$add": bson.A{
+		bson.M{"$subtract": bson.A{count("upvotes"), count("downvotes")}},
+		bson.M{"$multiply": bson.A{count("reply_count"), replyWeight}},
+	}}
+	order := bson.M{"$log10": bson.M{"$max": bson.A{bson.M{"$abs": net}, 1}}}
+	sign := bson.M{"$cmp": bson.A{net, 0}}
+	age := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{"$created_at", epoch}},
+		1000 * gravity,
+	}}
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"hot_score": bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{sign, order}}, age}}}}},
+	}
+}
+
+// RefreshHotScore recomputes hot_score from the vent's current counters in a
+// single server-side update, so concurrent vote and reply writes cannot leave a
+// stale score behind, and returns the new score.
+func (r *VentRepository) RefreshHotScore(ctx context.Context, id primitive.ObjectID, epoch time.Time, gravity, replyWeight float64) (float64, error) {
+	var v struct {
+		HotScore float64 `bson:"hot_score"`
+	}
+	err := config.DB.Collection(r.col).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		hotScoreUpdate(epoch, gravity, replyWeight),
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"hot_score": 1}),
+	).Decode(&v)
+	return v.HotScore, err
+}
+
+// BackfillScores gives vents that lack them a hot score and a zero trending
+// score, which the trending job raises once they are voted on.
+func (r *VentRepository) BackfillScores(ctx context.Context, epoch time.Time, gravity, replyWeight float64) error {
+	col := config.DB.Collection(r.col)
+	if _, err := col.UpdateMany(ctx, bson.M{"hot_score": bson.M{"$exists": false}}, hotScoreUpdate(epoch, gravity, replyWeight)); err != nil {
+		return err
+	}
+	_, err := col.UpdateMany(ctx,
+		bson.M{"trending_score": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"trending_score": 0}},
+	)
+	return err
+}
+
+// SetTrendingScores writes the given trending scores and zeroes every other
+// vent that still carries one.
+func (r *VentRepository) SetTrendingScores(ctx context.Context, scores map[primitive.ObjectID]float64) error {
+	col := config.DB.Collection(r.col)
+	ids := make([]primitive.ObjectID, 0, len(scores))
+	writes := make([]mongo.WriteModel, 0, len(scores))
+	for id, score := range scores {
+		ids = append(ids, id)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"trending_score": score}}))
+	}
+	if len(writes) > 0 {
+		if _, err := col.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	_, err := col.UpdateMany(ctx,
+		bson.M{"trending_score": bson.M{"$ne": 0}, "_id": bson.M{"$nin": ids}},
+		bson.M{"$set": bson.M{"trending_score": 0}},
+	)
+	return err
+}
+
+// ApplyVoteDelta adjusts the vent's vote counters and score by the given deltas.
+func (r *VentRepository) ApplyVoteDelta(ctx context.Context, id primitive.ObjectID, up, down int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"upvotes": up, "downvotes": down, "score": up - down}},
+	)
+	return err
+}
+
+// SetVoteCounts overwrites the vent's vote counters, e.g. after a recount.
+func (r *VentRepository) SetVoteCounts(ctx context.Context, id primitive.ObjectID, up, down int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"upvotes": up, "downvotes": down, "score": up - down}},
+	)
+	return err
+}
+
+// IncrementViews adds buffered view counts to their vents in one round trip.
+func (r *VentRepository) IncrementViews(ctx context.Context, counts map[primitive.ObjectID]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(counts))
+	for id, n := range counts {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$inc": bson.M{"views": n}}))
+	}
+	_, err := config.DB.Collection(r.col).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// UpdateContent replaces a vent's content and tags provided it has not changed
+// since prevUpdatedAt. It returns mongo.ErrNoDocuments if a concurrent edit or
+// delete got there first.
+func (r *VentRepository) UpdateContent(ctx context.Context, id primitive.ObjectID, prevUpdatedAt time.Time, content string, tags []string, simhash int64) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": false, "updated_at": prevUpdatedAt},
+		bson.M{
+			"$set": bson.M{"content": content, "tags": tags, "simhash": simhash, "updated_at": time.Now()},
+			"$inc": bson.M{"edit_count": 1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ReplaceTag retags every vent carrying a tag with another tag, deleted
+// vents included so they come back retagged if restored.
+func (r *VentRepository) ReplaceTag(ctx context.Context, from, into string) error {
+	col := config.DB.Collection(r.col)
+	if _, err := col.UpdateMany(ctx, bson.M{"tags": from}, bson.M{"$addToSet": bson.M{"tags": into}}); err != nil {
+		return err
+	}
+	_, err := col.UpdateMany(ctx, bson.M{"tags": from}, bson.M{"$pull": bson.M{"tags": from}})
+	return err
+}
+
+// RemoveTag strips a tag from every vent carrying it.
+func (r *VentRepository) RemoveTag(ctx context.Context, tag string) error {
+	_, err := config.DB.Collection(r.col).UpdateMany(ctx, bson.M{"tags": tag}, bson.M{"$pull": bson.M{"tags": tag}})
+	return err
+}
+
+// DistinctTags returns every tag carried by a vent, deleted vents included.
+func (r *VentRepository) DistinctTags(ctx context.Context) ([]string, error) {
+	values, err := config.DB.Collection(r.col).Distinct(ctx, "tags", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(values))
+	for _, v := range values {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// CountTags returns how many live vents carry each tag.
+func (r *VentRepository) CountTags(ctx context.Context) (map[string]int, error) {
+	cursor, err := config.DB.Collection(r.col).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_deleted": false}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Tag   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Tag] = row.Count
+	}
+	return counts, nil
+}
+
+// CountTag returns how many live vents carry a tag.
+func (r *VentRepository) CountTag(ctx context.Context, tag string) (int, error) {
+	n, err := config.DB.Collection(r.col).CountDocuments(ctx, bson.M{"tags": tag, "is_deleted": false})
+	return int(n), err
+}
+
+// RecentFingerprints returns the fingerprints of live vents posted since the
+// given time, newest first, by one author or by anyone when authorID is nil.
+func (r *VentRepository) RecentFingerprints(ctx context.Context, authorID *primitive.ObjectID, since time.Time, limit int64) ([]PostFingerprint, error) {
+	return recentFingerprints(ctx, config.DB.Collection(r.col), authorID, since, limit)
+}
+
+// Search returns one page of vents matching the filter, ranked by relevance
+// and recency.
+func (r *VentRepository) Search(ctx context.Context, f SearchFilter, epoch time.Time, gravity time.Duration, after *Cursor, limit int64) (models.Page[models.SearchHit], error) {
+	match := f.created(f.ventFilter(""))
+	match["$text"] = bson.M{"$search": f.Query}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		rankStage(epoch, gravity),
+		{{Key: "$project", Value: bson.M{
+			"target_type": bson.M{"$literal": models.TargetVent},
+			"vent_id":     "$_id",
+			"content":     1,
+			"tags":        1,
+			"kind":        1,
+			"rank":        1,
+			"created_at":  1,
+		}}},
+	}
+	return searchPage(ctx, config.DB.Collection(r.col), pipeline, after, limit)
+}
+
+// SoftDelete hides a vent and records who deleted it and why.
+func (r *VentRepository) SoftDelete(ctx context.Context, id, by primitive.ObjectID, reason string) error {
+	now := time.Now()
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": false},
+		bson.M{"$set": bson.M{
+			"is_deleted":    true,
+			"deleted_by":    by,
+			"deleted_at":    now,
+			"delete_reason": reason,
+			"updated_at":    now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Restore undoes a soft delete.
+func (r *VentRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": true},
+		bson.M{
+			"$set":   bson.M{"is_deleted": false, "updated_at": time.Now()},
+			"$unset": bson.M{"deleted_by": "", "deleted_at": "", "delete_reason": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// IncrementReplyCount adjusts the number of live replies on a vent.
+func (r *VentRepository) IncrementReplyCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"reply_count": delta}},
+	)
+	return err
+}
+
+// SetAcceptedReply records the accepted answer on a vent; nil clears it.
+func (r *VentRepository) SetAcceptedReply(ctx context.Context, id primitive.ObjectID, replyID *primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"accepted_reply_id": replyID}}
+	if replyID == nil {
+		update = bson.M{"$unset": bson.M{"accepted_reply_id": ""}}
+	}
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// IncrementSaveCount adjusts the number of users who saved a vent.
+func (r *VentRepository) IncrementSaveCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := config.DB.Collection(r.col).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"save_count": delta}},
+	)
+	return err
+}
+
+// AddReport counts one more distinct report of the given weight against a
+// vent. It returns the vent as it was before the update, so the caller can
+// tell whether this report crossed a threshold.
+func (r *VentRepository) AddReport(ctx context.Context, id primitive.ObjectID, weight float64) (*models.Vent, error) {
+	var v models.Vent
+	err := config.DB.Collection(r.col).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"report_count": 1, "report_weight": weight}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SetUnderReview hides a vent pending moderator review, or lifts the hold.
+// It returns mongo.ErrNoDocuments if the vent is already in that state.
+func (r *VentRepository) SetUnderReview(ctx context.Context, id primitive.ObjectID, underReview bool) error {
+	filter := bson.M{"_id": id, "under_review": true}
+	if underReview {
+		// content reported before review holds existed has no field
+		filter["under_review"] = bson.M{"$ne": true}
+	}
+	res, err := config.DB.Collection(r.col).UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"under_review": underReview}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ShadowByAuthor shadows every one of the author's vents until the given
+// time; nil makes them visible again.
+func (r *VentRepository) ShadowByAuthor(ctx context.Context, authorID primitive.ObjectID, until *time.Time) error {
+	update := bson.M{"$set": bson.M{"shadowed_until": until}}
+	if until == nil {
+		update = bson.M{"$unset": bson.M{"shadowed_until": ""}}
+	}
+	_, err := config.DB.Collection(r.col).UpdateMany(ctx, bson.M{"author_id": authorID}, update)
+	return err
 }
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type VentRevisionRepository struct{ col string }
+
+func NewVentRevisionRepository() *VentRevisionRepository {
+	return &VentRevisionRepository{col: "vent_revisions"}
+}
+
+func (r *VentRevisionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "vent_id", Value: 1}, {Key: "version", Value: -1}},
+	})
+	return err
+}
+
+func (r *VentRevisionRepository) Create(ctx context.Context, rev *models.VentRevision) error {
+	rev.ID = primitive.NewObjectID()
+	rev.CreatedAt = time.Now()
+	_, err := config.DB.Collection(r.col).InsertOne(ctx, rev)
+	return err
+}
+
+// FindByVent returns a vent's revisions, newest first.
+func (r *VentRevisionRepository) FindByVent(ctx context.Context, ventID primitive.ObjectID) ([]models.VentRevision, error) {
+	opts := &options.FindOptions{}
+	opts.SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := config.DB.Collection(r.col).Find(ctx, bson.M{"vent_id": ventID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.VentRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *VentRevisionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := config.DB.Collection(r.col).DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
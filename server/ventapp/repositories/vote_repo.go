@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type VoteRepository struct{ col string }
+
+func NewVoteRepository() *VoteRepository { return &VoteRepository{col: "votes"} }
+
+// EnsureIndexes allows one vote per user per target and backs recounts by target.
+func (r *VoteRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := config.DB.Collection(r.col).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}}},
+	})
+	return err
+}
+
+// Set stores the user's vote on a target and returns the value it replaced
+// (0 if there was none). A value of 0 clears the vote. The swap happens in a
+// single document operation, so concurrent calls for the same user and target
+// each see the value the previous one left behind.
+func (r *VoteRepository) Set(ctx context.Context, userID primitive.ObjectID, targetType string, targetID primitive.ObjectID, value int) (int, error) {
+	col := config.DB.Collection(r.col)
+	filter := bson.M{"user_id": userID, "target_type": targetType, "target_id": targetID}
+
+	var prev models.Vote
+	var err error
+	if value == 0 {
+		err = col.FindOneAndDelete(ctx, filter).Decode(&prev)
+	} else {
+		now := time.Now()
+		update := bson.M{
+			"$set":         bson.M{"value": value, "updated_at": now},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": now},
+		}
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+		err = col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&prev)
+	}
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return prev.Value, nil
+}
+
+// Find returns the user's vote on a target, or 0 if they have not voted.
+func (r *VoteRepository) Find(ctx context.Context, userID primitive.ObjectID, targetType string, targetID primitive.ObjectID) (int, error) {
+	var v models.Vote
+	err := config.DB.Collection(r.col).FindOne(ctx, bson.M{"user_id": userID, "target_type": targetType, "target_id": targetID}).Decode(&v)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return v.Value, nil
+}
+
+// Count tallies the up and down votes recorded for a target.
+func (r *VoteRepository) Count(ctx context.Context, targetType string, targetID primitive.ObjectID) (up, down int, err error) {
+	col := config.DB.Collection(r.col)
+	filter := bson.M{"target_type": targetType, "target_id": targetID}
+
+	n, err := col.CountDocuments(ctx, bson.M{"$and": bson.A{filter, bson.M{"value": 1}}})
+	if err != nil {
+		return 0, 0, err
+	}
+	m, err := col.CountDocuments(ctx, bson.M{"$and": bson.A{filter, bson.M{"value": -1}}})
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(n), int(m), nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInvalidScope is wrapped by ResolveScope when an id is unknown or the ids
+// do not belong together.
+var ErrInvalidScope = errors.New("invalid scope")
+
+var (
+	universityRepo = repositories.NewUniversityRepository()
+	departmentRepo = repositories.NewDepartmentRepository()
+	courseRepo     = repositories.NewCourseRepository()
+)
+
+// ResolveScope validates the given university, department and course ids and
+// fills in the parents that can be derived from a more specific id, so a vent
+// tagged with only a course still shows up in its department's feed.
+func ResolveScope(ctx context.Context, universityID, departmentID, courseID *primitive.ObjectID) (models.ResourceScope, error) {
+	scope := models.ResourceScope{UniversityID: universityID, DepartmentID: departmentID, CourseID: courseID}
+
+	if courseID != nil {
+		course, err := courseRepo.FindByID(ctx, *courseID)
+		if err == mongo.ErrNoDocuments {
+			return scope, fmt.Errorf("%w: unknown course_id", ErrInvalidScope)
+		}
+		if err != nil {
+			return scope, err
+		}
+		if scope.DepartmentID == nil {
+			scope.DepartmentID = &course.DepartmentID
+		} else if *scope.DepartmentID != course.DepartmentID {
+			return scope, fmt.Errorf("%w: course does not belong to department", ErrInvalidScope)
+		}
+	}
+
+	if scope.DepartmentID != nil {
+		dept, err := departmentRepo.FindByID(ctx, *scope.DepartmentID)
+		if err == mongo.ErrNoDocuments {
+			return scope, fmt.Errorf("%w: unknown department_id", ErrInvalidScope)
+		}
+		if err != nil {
+			return scope, err
+		}
+		if scope.UniversityID == nil {
+			scope.UniversityID = &dept.UniversityID
+		} else if *scope.UniversityID != dept.UniversityID {
+			return scope, fmt.Errorf("%w: department does not belong to university", ErrInvalidScope)
+		}
+	}
+
+	if scope.UniversityID != nil {
+		if _, err := universityRepo.FindByID(ctx, *scope.UniversityID); err == mongo.ErrNoDocuments {
+			return scope, fmt.Errorf("%w: unknown university_id", ErrInvalidScope)
+		} else if err != nil {
+			return scope, err
+		}
+	}
+
+	return scope, nil
+}
+
+// SetAcademicHome sets the university and department the user studies at,
+// which "mine" feeds, their home feed and their digest use. A department
+// fills in its university; passing neither clears both.
+func SetAcademicHome(ctx context.Context, userID primitive.ObjectID, universityID, departmentID *primitive.ObjectID) (models.ResourceScope, error) {
+	scope, err := ResolveScope(ctx, universityID, departmentID, nil)
+	if err != nil {
+		return scope, err
+	}
+	return scope, userRepo.SetAcademic(ctx, userID, scope.UniversityID, scope.DepartmentID)
+}
+
+// SetEnrolledCourses replaces the courses the user is enrolled in, which
+// their home feed favours. Every course must exist.
+func SetEnrolledCourses(ctx context.Context, userID primitive.ObjectID, courseIDs []primitive.ObjectID) error {
+	distinct := make([]primitive.ObjectID, 0, len(courseIDs))
+	seen := make(map[primitive.ObjectID]bool, len(courseIDs))
+	for _, id := range courseIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := courseRepo.FindByID(ctx, id); err == mongo.ErrNoDocuments {
+			return fmt.Errorf("%w: unknown course_id %s", ErrInvalidScope, id.Hex())
+		} else if err != nil {
+			return err
+		}
+		distinct = append(distinct, id)
+	}
+	return userRepo.SetCourses(ctx, userID, distinct)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotAppealable is returned when appealing an action that cannot be
+	// appealed, such as a moderator lifting a sanction.
+	ErrNotAppealable = errors.New("this action cannot be appealed")
+	// ErrAlreadyAppealed is returned when an action is appealed twice.
+	ErrAlreadyAppealed = errors.New("this action has already been appealed")
+)
+
+var appealRepo = repositories.NewAppealRepository()
+
+// FileAppeal records the affected user's appeal against a moderation action.
+// Each action can be appealed once.
+func FileAppeal(ctx context.Context, userID, actionID primitive.ObjectID, message string) (*models.Appeal, error) {
+	action, err := moderationRepo.FindByID(ctx, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if action.SubjectID != userID {
+		// other users' moderation history is not theirs to know about
+		return nil, mongo.ErrNoDocuments
+	}
+	if !models.Appealable(action.Action) {
+		return nil, ErrNotAppealable
+	}
+
+	a := &models.Appeal{
+		ActionID:     action.ID,
+		TargetType:   action.TargetType,
+		UserID:       userID,
+		Message:      message,
+		UniversityID: action.UniversityID,
+		DepartmentID: action.DepartmentID,
+		CourseID:     action.CourseID,
+	}
+	if err := appealRepo.Create(ctx, a); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyAppealed
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// AppealQueue returns one page of appeals with the given status, limited to
+// those the actor may decide: appeals against content in the scopes where
+// they moderate content, and appeals against sanctions in the scopes where
+// they manage users.
+func AppealQueue(ctx context.Context, actorID primitive.ObjectID, status string, after *repositories.Cursor, limit int64) (models.Page[models.Appeal], error) {
+	f := repositories.AppealFilter{Status: status}
+	global, bindings, err := BindingsWith(ctx, actorID, models.PermModerateContent)
+	if err != nil {
+		return models.Page[models.Appeal]{}, err
+	}
+	if !global {
+		f.ContentScopes = bindings
+	}
+	if global, bindings, err = BindingsWith(ctx, actorID, models.PermManageUsers); err != nil {
+		return models.Page[models.Appeal]{}, err
+	}
+	if !global {
+		f.UserScopes = bindings
+	}
+	return appealRepo.FindPage(ctx, f, after, limit)
+}
+
+// DecideAppeal accepts or rejects a pending appeal. Accepting reverses the
+// appealed action, which is recorded in the moderation log like any other.
+// Deciding needs the permission the original action needed: PermManageUsers
+// for sanctions, PermModerateContent for everything else. The appeal is
+// decided before the action is reversed, so two moderators accepting it at
+// once reverse it only once; if reversing fails it is pending again.
+func DecideAppeal(ctx context.Context, actorID, appealID primitive.ObjectID, accept bool, note string) (*models.Appeal, error) {
+	a, err := appealRepo.FindByID(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != models.AppealPending {
+		return nil, ErrConflict
+	}
+	action, err := moderationRepo.FindByID(ctx, a.ActionID)
+	if err != nil {
+		return nil, err
+	}
+
+	perm := models.PermModerateContent
+	if action.TargetType == models.TargetUser {
+		perm = models.PermManageUsers
+	}
+	scope := models.ResourceScope{UniversityID: action.UniversityID, DepartmentID: action.DepartmentID, CourseID: action.CourseID}
+	allowed, err := Can(ctx, actorID, perm, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	status := models.AppealRejected
+	if accept {
+		status = models.AppealAccepted
+	}
+	if err := appealRepo.Decide(ctx, a.ID, status, actorID, note); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	if accept {
+		if err := reverseAction(ctx, actorID, action, note); err != nil {
+			if rerr := appealRepo.Reopen(ctx, a.ID, actorID); rerr != nil {
+				log.Printf("reopening appeal %s failed: %v", a.ID.Hex(), rerr)
+			}
+			return nil, err
+		}
+	}
+	return appealRepo.FindByID(ctx, a.ID)
+}
+
+// reverseAction undoes an appealable moderation action. Actions that have
+// already been undone some other way are left alone.
+func reverseAction(ctx context.Context, actorID primitive.ObjectID, action *models.ModerationAction, note string) error {
+	reason := "appeal accepted"
+	if note != "" {
+		reason += ": " + note
+	}
+
+	var err error
+	switch action.Action {
+	case models.ModActionAutoHide, models.ModActionHold:
+		_, err = RestoreContent(ctx, actorID, action.TargetType, action.TargetID, reason)
+	case models.ModActionDelete:
+		switch action.TargetType {
+		case models.TargetVent:
+			_, err = RestoreVent(ctx, actorID, action.TargetID, reason)
+		case models.TargetReply:
+			_, err = RestoreReply(ctx, actorID, action.TargetID, reason)
+		}
+	case models.ModActionSuspend, models.ModActionBan, models.ModActionShadowBan:
+		if action.SanctionID != nil {
+			_, err = LiftSanction(ctx, actorID, *action.SanctionID, reason)
+		}
+	default:
+		return ErrNotAppealable
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	return err
+}
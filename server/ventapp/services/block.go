@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrBlocked is returned when replying to a vent or reply whose author has
+// blocked the replier.
+var ErrBlocked = errors.New("you cannot reply to this user")
+
+// ErrSelfBlock is returned when a user tries to block or mute themselves.
+var ErrSelfBlock = errors.New("you cannot block or mute yourself")
+
+var (
+	relationRepo   = repositories.NewRelationRepository()
+	mutedTagRepo   = repositories.NewMutedTagRepository()
+	hiddenVentRepo = repositories.NewHiddenVentRepository()
+)
+
+// BlockUser hides the target's vents and replies from the user and stops the
+// target replying to the user's posts or sending them realtime events. It
+// replaces a mute of the target.
+func BlockUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if err := checkRelationTarget(ctx, userID, targetID); err != nil {
+		return err
+	}
+	return relationRepo.Block(ctx, userID, targetID)
+}
+
+// UnblockUser lifts the user's block of the target, if any.
+func UnblockUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := relationRepo.Delete(ctx, userID, targetID, models.RelationBlock)
+	return err
+}
+
+// MuteUser hides the target's vents and replies from the user. Muting a
+// blocked user leaves the block in place.
+func MuteUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if err := checkRelationTarget(ctx, userID, targetID); err != nil {
+		return err
+	}
+	return relationRepo.Mute(ctx, userID, targetID)
+}
+
+// UnmuteUser lifts the user's mute of the target, if any. A block is not
+// affected.
+func UnmuteUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	_, err := relationRepo.Delete(ctx, userID, targetID, models.RelationMute)
+	return err
+}
+
+// checkRelationTarget makes sure the target of a block or mute is another
+// existing user.
+func checkRelationTarget(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if userID == targetID {
+		return ErrSelfBlock
+	}
+	_, err := userRepo.FindByID(ctx, targetID)
+	return err
+}
+
+// Relations returns the users the user blocked or muted, newest first. kind
+// limits them to blocks or mutes; empty returns both.
+func Relations(ctx context.Context, userID primitive.ObjectID, kind string) ([]models.Relation, error) {
+	return relationRepo.FindByUser(ctx, userID, kind)
+}
+
+// MuteTag hides vents carrying the tag from the user.
+func MuteTag(ctx context.Context, userID primitive.ObjectID, raw string) error {
+	name, err := NormaliseTag(ctx, raw)
+	if err != nil {
+		return err
+	}
+	return mutedTagRepo.Upsert(ctx, userID, name)
+}
+
+// UnmuteTag shows vents carrying the tag to the user again.
+func UnmuteTag(ctx context.Context, userID primitive.ObjectID, raw string) error {
+	name, err := NormaliseTag(ctx, raw)
+	if errors.Is(err, ErrBannedTag) {
+		// mutes of banned tags are kept, so they can still be removed
+		name, err = tagging.normalise(raw), nil
+	}
+	if err != nil {
+		return err
+	}
+	return mutedTagRepo.Delete(ctx, userID, name)
+}
+
+// MutedTags returns the tags the user muted, newest first.
+func MutedTags(ctx context.Context, userID primitive.ObjectID) ([]models.MutedTag, error) {
+	return mutedTagRepo.FindByUser(ctx, userID)
+}
+
+// HideVent hides a single vent from the user.
+func HideVent(ctx context.Context, userID, ventID primitive.ObjectID) error {
+	if _, err := ventRepo.FindByID(ctx, ventID); err != nil {
+		return err
+	}
+	return hiddenVentRepo.Upsert(ctx, userID, ventID)
+}
+
+// UnhideVent shows a hidden vent to the user again.
+func UnhideVent(ctx context.Context, userID, ventID primitive.ObjectID) error {
+	return hiddenVentRepo.Delete(ctx, userID, ventID)
+}
+
+// HiddenVents returns the vents the user hid, newest first.
+func HiddenVents(ctx context.Context, userID primitive.ObjectID) ([]models.HiddenVent, error) {
+	return hiddenVentRepo.FindByUser(ctx, userID)
+}
+
+// ViewerExclusions returns what the viewer blocked, muted or hid, to be left
+// out of feeds, replies and search. Anonymous viewers exclude nothing.
+func ViewerExclusions(ctx context.Context, viewerID *primitive.ObjectID) (repositories.Exclusions, error) {
+	var ex repositories.Exclusions
+	if viewerID == nil {
+		return ex, nil
+	}
+	relations, err := relationRepo.FindByUser(ctx, *viewerID, "")
+	if err != nil {
+		return ex, err
+	}
+	for _, r := range relations {
+		ex.Authors = append(ex.Authors, r.TargetID)
+	}
+	tags, err := mutedTagRepo.FindByUser(ctx, *viewerID)
+	if err != nil {
+		return ex, err
+	}
+	for _, t := range tags {
+		ex.Tags = append(ex.Tags, t.Tag)
+	}
+	hidden, err := hiddenVentRepo.FindByUser(ctx, *viewerID)
+	if err != nil {
+		return ex, err
+	}
+	for _, h := range hidden {
+		ex.Vents = append(ex.Vents, h.VentID)
+	}
+	return ex, nil
+}
+
+// Avoiders returns the users who should not receive realtime events about
+// content by authorID: those who blocked or muted the author and, when the
+// content belongs to a vent, those who blocked or muted its author, muted
+// one of its tags or hid it.
+func Avoiders(ctx context.Context, authorID primitive.ObjectID, vent *models.Vent) ([]primitive.ObjectID, error) {
+	authors := []primitive.ObjectID{authorID}
+	if vent != nil && vent.AuthorID != authorID {
+		authors = append(authors, vent.AuthorID)
+	}
+	ids, err := relationRepo.UserIDsByTargets(ctx, authors)
+	if err != nil || vent == nil {
+		return ids, err
+	}
+	muted, err := mutedTagRepo.UserIDsByTags(ctx, vent.Tags)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := hiddenVentRepo.UserIDsByVent(ctx, vent.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(ids, muted...), hidden...), nil
+}
+
+// checkNotBlocked returns ErrBlocked if any of the authors blocked the user.
+func checkNotBlocked(ctx context.Context, userID primitive.ObjectID, authorIDs ...primitive.ObjectID) error {
+	blocked, err := relationRepo.Blocks(ctx, authorIDs, userID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package services
+
+import "ventapp/server/ventapp/config"
+
+// Configure replaces the default tuning parameters with those from cfg. Call
+// it once at startup, before serving requests or starting background jobs.
+func Configure(cfg config.AppConfig) {
+	ranking = cfg.Ranking
+	editWindow = cfg.EditWindow
+	replies = cfg.Replies
+	moderation = cfg.Moderation
+	Views = NewViewCounter(cfg.Views)
+	Filters = NewContentFilter(cfg.Filters)
+	crisis = newCrisisDetector(cfg.Crisis)
+	spam = cfg.Spam
+	search = cfg.Search
+	tagging = newTagNormaliser(cfg.Tags)
+	feed = cfg.Feed
+	notify = cfg.Notify
+	bot = cfg.Telegram
+}
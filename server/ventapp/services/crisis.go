@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+)
+
+// CrisisSupport is shown to an author whose post signals a crisis.
+type CrisisSupport struct {
+	Message   string                  `json:"message"`
+	Resources []config.CrisisResource `json:"resources"`
+}
+
+// crisisDetector scores content against the configured lexicon.
+type crisisDetector struct {
+	cfg     config.CrisisConfig
+	phrases [][]rune
+	weights []float64
+}
+
+func newCrisisDetector(cfg config.CrisisConfig) *crisisDetector {
+	d := &crisisDetector{cfg: cfg}
+	for _, term := range cfg.Lexicon {
+		if folded := foldTerm(term.Phrase); len(folded) > 0 {
+			d.phrases = append(d.phrases, folded)
+			d.weights = append(d.weights, term.Weight)
+		}
+	}
+	return d
+}
+
+var crisis = newCrisisDetector(config.DefaultConfig().Crisis)
+
+// score adds up the weights of the lexicon phrases found in content, each
+// counted once however often it occurs.
+func (d *crisisDetector) score(content string) float64 {
+	text := []rune(content)
+	var total float64
+	for i, phrase := range d.phrases {
+		if len(matchTerms(text, [][]rune{phrase})) > 0 {
+			total += d.weights[i]
+		}
+	}
+	return total
+}
+
+// resources returns the help offered for a post in the given scope.
+func (d *crisisDetector) resources(ctx context.Context, scope models.ResourceScope) []config.CrisisResource {
+	res := d.cfg.Resources
+	var out []config.CrisisResource
+	var country []config.CrisisResource
+	if scope.UniversityID != nil {
+		out = append(out, res.Universities[scope.UniversityID.Hex()]...)
+		u, err := universityRepo.FindByID(ctx, *scope.UniversityID)
+		if err != nil {
+			log.Printf("university lookup failed for crisis resources %s: %v", scope.UniversityID.Hex(), err)
+		} else {
+			country = res.Countries[u.Country]
+		}
+	}
+	if len(country) == 0 {
+		country = res.Default
+	}
+	return append(out, country...)
+}
+
+// crisisStage flags content that signals self-harm. It is held for a
+// moderator on the priority queue rather than published, and its author is
+// offered support.
+type crisisStage struct{}
+
+func (crisisStage) Screen(ctx context.Context, s *Screening) error {
+	d := crisis
+	if d.cfg.Threshold <= 0 || d.score(s.Content) < d.cfg.Threshold {
+		return nil
+	}
+	s.flag(models.FilterHold, "possible self-harm")
+	s.Priority = true
+	s.Support = &CrisisSupport{Message: d.cfg.Message, Resources: d.resources(ctx, s.Scope)}
+	return nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"unicode"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// digestReplies is the most reply notifications a digest lists.
+	digestReplies = 10
+	// digestExcerpt is the most runes of a vent a digest quotes.
+	digestExcerpt = 120
+)
+
+// digestLink is one line of a digest.
+type digestLink struct {
+	Text string
+	URL  string
+}
+
+// digestData is what the digest templates render.
+type digestData struct {
+	Name       string
+	Period     string
+	Replies    []digestLink
+	Unread     int64
+	Department string
+	Trending   []digestLink
+	AppURL     string
+}
+
+// RunDigestJob sends email digests as they fall due, checking every
+// DigestInterval until ctx is cancelled. It does nothing until UseMailer is
+// called.
+func RunDigestJob(ctx context.Context) {
+	ticker := time.NewTicker(notify.DigestInterval)
+	defer ticker.Stop()
+	for {
+		if mailer != nil {
+			if err := SendDueDigests(ctx, time.Now()); err != nil {
+				log.Printf("digest job failed: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDueDigests sends a digest to every subscriber who has not had one
+// since their latest scheduled time, unless it is their quiet hours. Each
+// digest is claimed before it is sent, so concurrent jobs never send it
+// twice; one that fails to send is logged and skipped until the next slot.
+func SendDueDigests(ctx context.Context, now time.Time) error {
+	return prefsRepo.EachDigestSubscriber(ctx, func(p models.NotificationPrefs) error {
+		if p.LastDigestAt != nil && !p.LastDigestAt.Before(digestSlot(&p, now)) {
+			return nil
+		}
+		if quiet(&p, now) {
+			return nil
+		}
+		claimed, err := prefsRepo.ClaimDigest(ctx, p.UserID, p.LastDigestAt, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+
+		since := now.AddDate(0, 0, -1)
+		if p.Digest == models.DigestWeekly {
+			since = now.AddDate(0, 0, -7)
+		}
+		if p.LastDigestAt != nil && p.LastDigestAt.After(since) {
+			since = *p.LastDigestAt
+		}
+		if err := sendDigest(ctx, &p, since); err != nil {
+			log.Printf("digest failed for user %s: %v", p.UserID.Hex(), err)
+		}
+		return nil
+	})
+}
+
+// digestSlot returns the latest time at or before now that a digest was
+// scheduled for under the settings: DigestHour in the user's time zone,
+// every day or on DigestWeekday.
+func digestSlot(p *models.NotificationPrefs, now time.Time) time.Time {
+	local := now.In(prefsLocation(p))
+	slot := time.Date(local.Year(), local.Month(), local.Day(), notify.DigestHour, 0, 0, 0, local.Location())
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if p.Digest == models.DigestWeekly {
+		back := (int(slot.Weekday()) - int(notify.DigestWeekday) + 7) % 7
+		slot = slot.AddDate(0, 0, -back)
+	}
+	return slot
+}
+
+// sendDigest mails the user the replies to their posts since the given time
+// and what is trending in their department. Nothing is sent if there is
+// neither.
+func sendDigest(ctx context.Context, p *models.NotificationPrefs, since time.Time) error {
+	u, err := userRepo.FindByID(ctx, p.UserID)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return nil
+	}
+
+	data := digestData{Name: u.DisplayName, Period: "today", AppURL: notify.AppURL}
+	if p.Digest == models.DigestWeekly {
+		data.Period = "this week"
+	}
+	replies, err := notificationRepo.FindSince(ctx, u.ID, models.NotifyReply, since, digestReplies)
+	if err != nil {
+		return err
+	}
+	for _, n := range replies {
+		link := digestLink{Text: n.Message(), URL: notify.AppURL}
+		if n.VentID != nil {
+			link.URL = ventLink(*n.VentID)
+		}
+		data.Replies = append(data.Replies, link)
+	}
+	if data.Unread, err = notificationRepo.CountUnread(ctx, u.ID); err != nil {
+		return err
+	}
+
+	if u.DepartmentID != nil {
+		dept, err := departmentRepo.FindByID(ctx, *u.DepartmentID)
+		if err != nil {
+			return err
+		}
+		exclude, err := ViewerExclusions(ctx, &u.ID)
+		if err != nil {
+			return err
+		}
+		page, err := ventRepo.FindPage(ctx, repositories.VentFilter{
+			DepartmentID: u.DepartmentID,
+			ViewerID:     &u.ID,
+			Exclude:      exclude,
+		}, repositories.SortTrending, nil, notify.DigestTrending)
+		if err != nil {
+			return err
+		}
+		data.Department = dept.Name
+		for _, v := range page.Items {
+			data.Trending = append(data.Trending, digestLink{Text: excerpt(v.Content, digestExcerpt), URL: ventLink(v.ID)})
+		}
+	}
+
+	if len(data.Replies) == 0 && len(data.Trending) == 0 {
+		return nil
+	}
+	return sendEmail(ctx, u, "Your "+p.Digest+" UniQ&A digest", "digest", data)
+}
+
+// excerpt cuts s to at most n runes, on a word boundary where it can.
+func excerpt(s string, n int) string {
+	text := []rune(s)
+	if len(text) <= n {
+		return s
+	}
+	end := n
+	for end > 0 && !unicode.IsSpace(text[end]) {
+		end--
+	}
+	if end == 0 {
+		end = n
+	}
+	return string(text[:end]) + "…"
+}
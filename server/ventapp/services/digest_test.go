@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ventapp/server/ventapp/mail"
+	"ventapp/server/ventapp/models"
+)
+
+func TestDigestSlot(t *testing.T) {
+	// digests go out at 18:00 local time, weekly ones on Sundays; the
+	// default zone, Africa/Addis_Ababa, is UTC+3
+	addis, err := time.LoadLocation("Africa/Addis_Ababa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		digest string
+		tz     string
+		now    time.Time
+		want   time.Time
+	}{
+		{"daily before the hour", models.DigestDaily, "", time.Date(2026, 10, 21, 14, 59, 0, 0, time.UTC), time.Date(2026, 10, 20, 18, 0, 0, 0, addis)},
+		{"daily at the hour", models.DigestDaily, "", time.Date(2026, 10, 21, 15, 0, 0, 0, time.UTC), time.Date(2026, 10, 21, 18, 0, 0, 0, addis)},
+		{"daily after local midnight", models.DigestDaily, "", time.Date(2026, 10, 21, 21, 30, 0, 0, time.UTC), time.Date(2026, 10, 21, 18, 0, 0, 0, addis)},
+		{"weekly midweek", models.DigestWeekly, "", time.Date(2026, 10, 21, 15, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 18, 0, 0, 0, addis)},
+		{"weekly sunday before the hour", models.DigestWeekly, "", time.Date(2026, 10, 25, 14, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 18, 0, 0, 0, addis)},
+		{"weekly sunday at the hour", models.DigestWeekly, "", time.Date(2026, 10, 25, 15, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 18, 0, 0, 0, addis)},
+		{"own zone", models.DigestDaily, "UTC", time.Date(2026, 10, 21, 17, 0, 0, 0, time.UTC), time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &models.NotificationPrefs{Digest: tt.digest, Timezone: tt.tz}
+			if got := digestSlot(p, tt.now); !got.Equal(tt.want) {
+				t.Errorf("digestSlot(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestRendersToFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	prev := mailer
+	mailer = mail.FileMailer{Dir: dir}
+	t.Cleanup(func() { mailer = prev })
+
+	u := &models.User{DisplayName: "Abebe", Email: "abebe@example.edu"}
+	data := digestData{
+		Name:       u.DisplayName,
+		Period:     "this week",
+		Replies:    []digestLink{{Text: "3 people replied to your vent", URL: notify.AppURL + "/post/1"}},
+		Unread:     4,
+		Department: "Computer Science",
+		Trending:   []digestLink{{Text: excerpt("Finals week & the <library> hours", digestExcerpt), URL: notify.AppURL + "/post/2"}},
+		AppURL:     notify.AppURL,
+	}
+	if err := sendEmail(context.Background(), u, "Your weekly UniQ&A digest", "digest", data); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got %d messages (%v), want 1", len(files), err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	msg, err := netmail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to := msg.Header.Get("To"); to != u.Email {
+		t.Errorf("To = %q, want %q", to, u.Email)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Your weekly UniQ&A digest" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	bodies := map[string]string{}
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kind, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[kind] = string(body)
+	}
+
+	for kind, want := range map[string][]string{
+		"text/plain": {"Hi Abebe,", "this week", "3 people replied to your vent", "4 unread notifications", "Trending in Computer Science", "Finals week & the <library> hours"},
+		"text/html":  {"Abebe", "3 people replied to your vent", "Computer Science", "Finals week &amp; the &lt;library&gt; hours"},
+	} {
+		body, ok := bodies[kind]
+		if !ok {
+			t.Errorf("no %s part", kind)
+			continue
+		}
+		for _, s := range want {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s part is missing %q:\n%s", kind, s, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"ventapp/server/ventapp/mail"
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt.tmpl"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html.tmpl"))
+)
+
+// mailer sends email; nil until UseMailer is called, in which case no email
+// is sent.
+var mailer mail.Mailer
+
+// UseMailer sends email notifications and digests through m. It is called
+// at startup, before any notification is sent.
+func UseMailer(m mail.Mailer) {
+	mailer = m
+	RegisterChannel(emailChannel{})
+}
+
+// emailChannel sends notifications by email, to users who have an address.
+type emailChannel struct{}
+
+func (emailChannel) Name() string { return models.ChannelEmail }
+
+func (emailChannel) Send(ctx context.Context, u *models.User, n models.Notification) error {
+	if u.Email == "" {
+		return nil
+	}
+	link := notify.AppURL
+	if n.VentID != nil {
+		link = ventLink(*n.VentID)
+	}
+	return sendEmail(ctx, u, n.Message(), "notification", map[string]any{
+		"Name":    u.DisplayName,
+		"Message": n.Message(),
+		"URL":     link,
+		"AppURL":  notify.AppURL,
+	})
+}
+
+// sendEmail renders the named text and HTML templates with data and mails
+// them to the user.
+func sendEmail(ctx context.Context, u *models.User, subject, name string, data any) error {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html.tmpl", data); err != nil {
+		return err
+	}
+	return mailer.Send(ctx, mail.Message{
+		From:    notify.MailFrom,
+		To:      u.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	})
+}
+
+// ventLink is the web address of a vent.
+func ventLink(id primitive.ObjectID) string {
+	return notify.AppURL + "/post/" + id.Hex()
+}
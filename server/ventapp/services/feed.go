@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var feed = config.DefaultConfig().Feed
+
+var (
+	feedSessionRepo = repositories.NewFeedSessionRepository()
+	seenRepo        = repositories.NewSeenVentRepository()
+)
+
+// HomeFeed returns one page of the user's home feed: recent vents from their
+// followed tags, courses, department and university blended with globally
+// hot ones, leaving out vents they have already seen. The first page ranks
+// the feed once; later pages are read from that ranking through the cursor,
+// so they never repeat a vent. What the user blocked, muted or hid is left
+// out, including since the feed was ranked. A cursor from an expired session
+// returns repositories.ErrInvalidCursor.
+func HomeFeed(ctx context.Context, userID primitive.ObjectID, after *repositories.Cursor, limit int64) (models.Page[models.Vent], error) {
+	if limit <= 0 {
+		limit = repositories.DefaultPageLimit
+	}
+	if limit > repositories.MaxPageLimit {
+		limit = repositories.MaxPageLimit
+	}
+
+	exclude, err := ViewerExclusions(ctx, &userID)
+	if err != nil {
+		return models.Page[models.Vent]{}, err
+	}
+	var session *models.FeedSession
+	offset := 0
+	if after == nil {
+		if session, err = rankHomeFeed(ctx, userID, exclude); err != nil {
+			return models.Page[models.Vent]{}, err
+		}
+	} else {
+		session, err = feedSessionRepo.FindByID(ctx, after.ID, userID, time.Now().Add(-feed.SessionTTL))
+		if err == mongo.ErrNoDocuments {
+			return models.Page[models.Vent]{}, repositories.ErrInvalidCursor
+		}
+		if err != nil {
+			return models.Page[models.Vent]{}, err
+		}
+		offset = int(after.Value)
+	}
+	if offset < 0 || offset > len(session.VentIDs) {
+		return models.Page[models.Vent]{}, repositories.ErrInvalidCursor
+	}
+
+	end := min(offset+int(limit), len(session.VentIDs))
+	ids := session.VentIDs[offset:end]
+	vents, err := ventRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return models.Page[models.Vent]{}, err
+	}
+	items := make([]models.Vent, 0, len(ids))
+	for _, id := range ids {
+		// vents deleted, held, shadowed or excluded since the feed was ranked
+		// drop out
+		if v, ok := vents[id]; ok && !v.UnderReview && v.VisibleTo(&userID) && !exclude.Hides(v) {
+			items = append(items, v)
+		}
+	}
+
+	page := models.Page[models.Vent]{Items: items, HasMore: end < len(session.VentIDs)}
+	if page.HasMore {
+		page.NextCursor = repositories.Cursor{Value: float64(end), CreatedAt: session.CreatedAt, ID: session.ID}.Encode()
+	}
+	return page, nil
+}
+
+// rankHomeFeed ranks a new feed session for the user, leaving out what they
+// have seen or excluded, and prunes their expired sessions and old seen
+// records.
+func rankHomeFeed(ctx context.Context, userID primitive.ObjectID, exclude repositories.Exclusions) (*models.FeedSession, error) {
+	now := time.Now()
+	if err := feedSessionRepo.DeleteBefore(ctx, userID, now.Add(-feed.SessionTTL)); err != nil {
+		log.Printf("feed session prune failed for user %s: %v", userID.Hex(), err)
+	}
+	if err := seenRepo.DeleteBefore(ctx, userID, now.Add(-feed.SeenTTL)); err != nil {
+		log.Printf("seen vent prune failed for user %s: %v", userID.Hex(), err)
+	}
+
+	u, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := FollowedTagNames(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := ventRepo.RankFeed(ctx, repositories.FeedQuery{
+		ViewerID:     userID,
+		Since:        now.Add(-feed.Window),
+		SeenSince:    now.Add(-feed.SeenTTL),
+		Exclude:      exclude,
+		Tags:         tags,
+		UniversityID: u.UniversityID,
+		DepartmentID: u.DepartmentID,
+		CourseIDs:    u.CourseIDs,
+		Weights:      feed.Weights,
+	}, feed.Size)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.FeedSession{UserID: userID, VentIDs: ids}
+	if err := feedSessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// MarkSeen keeps vents the user has seen out of their home feed. Sessions
+// already ranked are not affected.
+func MarkSeen(ctx context.Context, userID primitive.ObjectID, ventIDs []primitive.ObjectID) error {
+	return seenRepo.Mark(ctx, userID, ventIDs)
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidFilterRule is returned when a filter rule is incomplete or its
+// pattern does not compile.
+var ErrInvalidFilterRule = errors.New("invalid filter rule")
+
+var (
+	filterRuleRepo = repositories.NewFilterRuleRepository()
+
+	// phonePattern matches numbers shaped like phone numbers: starting with
+	// + or split into groups, optionally with a bracketed area code. Bare
+	// runs of digits, such as student or order numbers, are left alone.
+	phonePattern = regexp.MustCompile(`\+\d[\d\s\-.()]*\d|(?:\(\d{2,5}\)\s?|\b\d{2,5}[\s\-.])\d{2,5}(?:[\s\-.]\d{2,5}){0,3}\b`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	linkPattern  = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.`)
+)
+
+// defaultFilterRules are installed the first time the rules collection is
+// found empty.
+var defaultFilterRules = []models.FilterRule{
+	{Name: "Phone numbers", Kind: models.RulePhone, Action: models.FilterMask, Enabled: true},
+	{Name: "Email addresses", Kind: models.RuleEmail, Action: models.FilterMask, Enabled: true},
+	{Name: "Maximum length", Kind: models.RuleMaxLength, Action: models.FilterReject, Limit: 5000, Enabled: true},
+	{Name: "Too many links", Kind: models.RuleMaxLinks, Action: models.FilterHold, Limit: 3, Enabled: true},
+}
+
+// A phone number match must hold between minPhoneDigits and maxPhoneDigits
+// digits, which keeps dates and short figures out.
+const (
+	minPhoneDigits = 9
+	maxPhoneDigits = 15
+)
+
+// leetFolds maps look-alike characters to the letter they stand in for, so
+// that "h4te" matches a blocklisted "hate".
+var leetFolds = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// FilterMatch is one rule that matched a piece of content.
+type FilterMatch struct {
+	RuleID primitive.ObjectID `json:"rule_id"`
+	Rule   string             `json:"rule"`
+	Kind   string             `json:"kind"`
+	Action string             `json:"action"`
+}
+
+// FilterResult is the outcome of running the content filter. Action is the
+// most severe action of any matching rule, empty when nothing matched, and
+// Content is the input with every masked span replaced by asterisks.
+type FilterResult struct {
+	Action  string        `json:"action,omitempty"`
+	Content string        `json:"content"`
+	Matches []FilterMatch `json:"matches"`
+}
+
+// compiledRule is a filter rule ready to run.
+type compiledRule struct {
+	rule  models.FilterRule
+	re    *regexp.Regexp
+	terms [][]rune
+}
+
+// ContentFilter holds the compiled filter rules. They are loaded from the
+// database and swapped in whole, so a check always sees one consistent set.
+type ContentFilter struct {
+	cfg   config.FilterConfig
+	mu    sync.RWMutex
+	rules []compiledRule
+}
+
+func NewContentFilter(cfg config.FilterConfig) *ContentFilter {
+	return &ContentFilter{cfg: cfg}
+}
+
+// Filters is the process-wide content filter run on vents and replies.
+var Filters = NewContentFilter(config.DefaultConfig().Filters)
+
+// Seed installs the default rules if there are none at all.
+func (f *ContentFilter) Seed(ctx context.Context) error {
+	n, err := filterRuleRepo.Count(ctx)
+	if err != nil || n > 0 {
+		return err
+	}
+	for _, rule := range defaultFilterRules {
+		rule := rule
+		if err := filterRuleRepo.Create(ctx, &rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load replaces the active rules with the enabled rules in the database. A
+// rule that no longer compiles is skipped rather than failing the whole set.
+func (f *ContentFilter) Load(ctx context.Context) error {
+	rules, err := filterRuleRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		cr, err := compileRule(rule)
+		if err != nil {
+			log.Printf("skipping filter rule %s: %v", rule.ID.Hex(), err)
+			continue
+		}
+		compiled = append(compiled, cr)
+	}
+
+	f.mu.Lock()
+	f.rules = compiled
+	f.mu.Unlock()
+	return nil
+}
+
+// Run reloads the rules every ReloadInterval until ctx is done. Seed and
+// Load the rules once before serving requests, so no content is screened
+// without them.
+func (f *ContentFilter) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Load(ctx); err != nil {
+				log.Printf("filter rule reload failed: %v", err)
+			}
+		}
+	}
+}
+
+// Check runs every active rule against content.
+func (f *ContentFilter) Check(content string) FilterResult {
+	f.mu.RLock()
+	rules := f.rules
+	f.mu.RUnlock()
+	return checkRules(rules, content)
+}
+
+// CheckTag runs the rules that match spans of text against a tag; length and
+// link limits only apply to content.
+func (f *ContentFilter) CheckTag(tag string) FilterResult {
+	f.mu.RLock()
+	rules := f.rules
+	f.mu.RUnlock()
+	spanRules := make([]compiledRule, 0, len(rules))
+	for _, cr := range rules {
+		if cr.rule.Masks() {
+			spanRules = append(spanRules, cr)
+		}
+	}
+	return checkRules(spanRules, tag)
+}
+
+func checkRules(rules []compiledRule, content string) FilterResult {
+	res := FilterResult{Content: content, Matches: []FilterMatch{}}
+	text := []rune(content)
+	masked := make([]bool, len(text))
+
+	for _, cr := range rules {
+		spans, matched := cr.match(content, text)
+		if !matched {
+			continue
+		}
+		res.Matches = append(res.Matches, FilterMatch{RuleID: cr.rule.ID, Rule: cr.rule.Name, Kind: cr.rule.Kind, Action: cr.rule.Action})
+		res.Action = severest(res.Action, cr.rule.Action)
+		if cr.rule.Action == models.FilterMask {
+			for _, s := range spans {
+				for i := s[0]; i < s[1]; i++ {
+					masked[i] = true
+				}
+			}
+		}
+	}
+
+	if res.Action != "" {
+		out := make([]rune, len(text))
+		for i, r := range text {
+			if masked[i] && !unicode.IsSpace(r) {
+				r = '*'
+			}
+			out[i] = r
+		}
+		res.Content = string(out)
+	}
+	return res
+}
+
+// match reports whether the rule matches and, for rules that match spans of
+// text, the rune spans it matched.
+func (cr compiledRule) match(content string, text []rune) ([][2]int, bool) {
+	switch cr.rule.Kind {
+	case models.RuleMaxLength:
+		return nil, len(text) > cr.rule.Limit
+	case models.RuleMaxLinks:
+		return nil, len(linkPattern.FindAllStringIndex(content, -1)) > cr.rule.Limit
+	case models.RuleKeyword:
+		spans := matchTerms(text, cr.terms)
+		return spans, len(spans) > 0
+	}
+
+	var spans [][2]int
+	for _, loc := range cr.re.FindAllStringIndex(content, -1) {
+		if cr.rule.Kind == models.RulePhone && !phoneLength(content[loc[0]:loc[1]]) {
+			continue
+		}
+		start := utf8.RuneCountInString(content[:loc[0]])
+		spans = append(spans, [2]int{start, start + utf8.RuneCountInString(content[loc[0]:loc[1]])})
+	}
+	return spans, len(spans) > 0
+}
+
+// phoneLength reports whether a phone pattern match holds as many digits as
+// a phone number.
+func phoneLength(match string) bool {
+	digits := 0
+	for _, r := range match {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits >= minPhoneDigits && digits <= maxPhoneDigits
+}
+
+// compileRule validates a rule and prepares it for matching.
+func compileRule(rule models.FilterRule) (compiledRule, error) {
+	cr := compiledRule{rule: rule}
+	if strings.TrimSpace(rule.Name) == "" {
+		return cr, fmt.Errorf("%w: name is required", ErrInvalidFilterRule)
+	}
+	switch rule.Action {
+	case models.FilterReject, models.FilterHold:
+	case models.FilterMask:
+		if !rule.Masks() {
+			return cr, fmt.Errorf("%w: %s rules cannot mask", ErrInvalidFilterRule, rule.Kind)
+		}
+	default:
+		return cr, fmt.Errorf("%w: action must be one of reject, hold, mask", ErrInvalidFilterRule)
+	}
+
+	switch rule.Kind {
+	case models.RuleKeyword:
+		for _, term := range rule.Terms {
+			if folded := foldTerm(term); len(folded) > 0 {
+				cr.terms = append(cr.terms, folded)
+			}
+		}
+		if len(cr.terms) == 0 {
+			return cr, fmt.Errorf("%w: keyword rules need at least one term", ErrInvalidFilterRule)
+		}
+	case models.RuleRegex:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return cr, fmt.Errorf("%w: %v", ErrInvalidFilterRule, err)
+		}
+		if re.MatchString("") {
+			return cr, fmt.Errorf("%w: pattern matches empty text", ErrInvalidFilterRule)
+		}
+		cr.re = re
+	case models.RulePhone:
+		cr.re = phonePattern
+	case models.RuleEmail:
+		cr.re = emailPattern
+	case models.RuleMaxLength, models.RuleMaxLinks:
+		if rule.Limit < 0 || (rule.Kind == models.RuleMaxLength && rule.Limit == 0) {
+			return cr, fmt.Errorf("%w: limit is out of range", ErrInvalidFilterRule)
+		}
+	default:
+		return cr, fmt.Errorf("%w: unknown kind %q", ErrInvalidFilterRule, rule.Kind)
+	}
+	return cr, nil
+}
+
+// foldRune normalises a character for keyword matching: letters are
+// lower-cased and look-alike digits and symbols replaced by their letter.
+// Scripts without case, such as Ethiopic, pass through unchanged.
+func foldRune(r rune) rune {
+	if f, ok := leetFolds[r]; ok {
+		return f
+	}
+	return unicode.ToLower(r)
+}
+
+// isWordRune reports whether r is part of a word for keyword boundaries.
+// Ethiopic word separators such as ፡ and ። are punctuation, not word runes.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+}
+
+func foldTerm(term string) []rune {
+	term = strings.TrimSpace(term)
+	folded := make([]rune, 0, len(term))
+	for _, r := range term {
+		folded = append(folded, foldRune(r))
+	}
+	return folded
+}
+
+// matchTerms returns the rune spans of text where any term occurs as a whole
+// word (or words), after folding both.
+func matchTerms(text []rune, terms [][]rune) [][2]int {
+	folded := make([]rune, len(text))
+	for i, r := range text {
+		folded[i] = foldRune(r)
+	}
+
+	var spans [][2]int
+	for i := range folded {
+		if i > 0 && isWordRune(text[i-1]) {
+			continue
+		}
+		for _, term := range terms {
+			end := i + len(term)
+			if end > len(folded) || (end < len(text) && isWordRune(text[end])) {
+				continue
+			}
+			if runesEqual(folded[i:end], term) {
+				spans = append(spans, [2]int{i, end})
+				break
+			}
+		}
+	}
+	return spans
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// severest returns the more severe of two filter actions; empty means none.
+func severest(a, b string) string {
+	rank := map[string]int{"": 0, models.FilterMask: 1, models.FilterHold: 2, models.FilterReject: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// requireGlobalModerator checks that the actor holds PermModerateContent
+// globally, as managing site-wide settings such as filter rules and tags
+// requires.
+func requireGlobalModerator(ctx context.Context, actorID primitive.ObjectID) error {
+	allowed, err := Can(ctx, actorID, models.PermModerateContent, models.ResourceScope{})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// FilterRules returns every filter rule, enabled or not, in the order they run.
+func FilterRules(ctx context.Context, actorID primitive.ObjectID) ([]models.FilterRule, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	return filterRuleRepo.FindAll(ctx)
+}
+
+// CreateFilterRule validates and stores a new rule and puts it into effect.
+func CreateFilterRule(ctx context.Context, actorID primitive.ObjectID, rule *models.FilterRule) error {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return err
+	}
+	if _, err := compileRule(*rule); err != nil {
+		return err
+	}
+	rule.UpdatedBy = actorID
+	if err := filterRuleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+	reloadFilters(ctx)
+	return nil
+}
+
+// FilterRulePatch holds the editable fields of a filter rule; nil fields are
+// left unchanged. A rule's kind cannot change.
+type FilterRulePatch struct {
+	Name    *string
+	Action  *string
+	Terms   *[]string
+	Pattern *string
+	Limit   *int
+	Enabled *bool
+}
+
+// UpdateFilterRule applies a patch to a rule and puts it into effect.
+func UpdateFilterRule(ctx context.Context, actorID, ruleID primitive.ObjectID, patch FilterRulePatch) (*models.FilterRule, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	rule, err := filterRuleRepo.FindByID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name != nil {
+		rule.Name = *patch.Name
+	}
+	if patch.Action != nil {
+		rule.Action = *patch.Action
+	}
+	if patch.Terms != nil {
+		rule.Terms = *patch.Terms
+	}
+	if patch.Pattern != nil {
+		rule.Pattern = *patch.Pattern
+	}
+	if patch.Limit != nil {
+		rule.Limit = *patch.Limit
+	}
+	if patch.Enabled != nil {
+		rule.Enabled = *patch.Enabled
+	}
+	if _, err := compileRule(*rule); err != nil {
+		return nil, err
+	}
+
+	rule.UpdatedBy = actorID
+	if err := filterRuleRepo.Replace(ctx, rule); err != nil {
+		return nil, err
+	}
+	reloadFilters(ctx)
+	return rule, nil
+}
+
+// DeleteFilterRule removes a rule. If every rule is deleted the defaults come
+// back on the next restart, so disable rules instead to switch them all off.
+func DeleteFilterRule(ctx context.Context, actorID, ruleID primitive.ObjectID) error {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return err
+	}
+	if err := filterRuleRepo.Delete(ctx, ruleID); err != nil {
+		return err
+	}
+	reloadFilters(ctx)
+	return nil
+}
+
+// TestFilter runs content through the active rules without posting it.
+func TestFilter(ctx context.Context, actorID primitive.ObjectID, content string) (FilterResult, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return FilterResult{}, err
+	}
+	return Filters.Check(content), nil
+}
+
+// reloadFilters puts rule changes into effect on this server straight away;
+// other servers pick them up on their next periodic reload.
+func reloadFilters(ctx context.Context) {
+	if err := Filters.Load(ctx); err != nil {
+		log.Printf("filter rule reload failed: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"slices"
+
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxMentions is the most users one vent or reply notifies by mentioning
+// them, so a post cannot be used to ping a whole class.
+const maxMentions = 10
+
+// mentionPattern matches @username where the @ does not follow a word
+// character, so email addresses are not mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+)`)
+
+// mentions returns the distinct usernames content mentions, in order, up to
+// maxMentions.
+func mentions(content string) []string {
+	var names []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
+
+// notifyMentions tells the users content mentions that they were mentioned
+// in the vent or reply, except the author and the users in skip, who are
+// told about it otherwise.
+func notifyMentions(ctx context.Context, authorID primitive.ObjectID, targetType string, targetID, ventID primitive.ObjectID, content string, skip ...primitive.ObjectID) {
+	names := mentions(content)
+	if len(names) == 0 {
+		return
+	}
+	users, err := userRepo.FindByUsernames(ctx, names)
+	if err != nil {
+		log.Printf("mention lookup failed for %s %s: %v", targetType, targetID.Hex(), err)
+		return
+	}
+	for _, u := range users {
+		if slices.Contains(skip, u.ID) {
+			continue
+		}
+		notifier.Notify(ctx, models.Notification{
+			UserID:     u.ID,
+			Type:       models.NotifyMention,
+			TargetType: targetType,
+			TargetID:   targetID,
+			VentID:     &ventID,
+		}, &authorID)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"ventapp/server/ventapp/repositories"
+)
+
+var migrationRepo = repositories.NewMigrationRepository()
+
+// migration brings documents written by an earlier version up to date. It
+// must be safe to run again, in case two instances start at once.
+type migration struct {
+	name string
+	run  func(context.Context) error
+}
+
+// migrations run in order; new ones are appended.
+var migrations = []migration{
+	{"vent_sort_fields", backfillVentSortFields},
+	{"vent_scores", backfillScores},
+	{"user_academic", backfillUserAcademic},
+	{"vent_saved_by", importSavedBy},
+	{"legacy_reports", backfillLegacyReports},
+	{"appeal_target_types", backfillAppealTargetTypes},
+	{"vent_tags", normaliseVentTags},
+}
+
+// Migrate runs the data migrations not yet applied and records them. Call it
+// once at startup, after Configure and before serving requests.
+func Migrate(ctx context.Context) error {
+	for _, m := range migrations {
+		applied, err := migrationRepo.Applied(ctx, m.name)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+		log.Printf("running migration %s", m.name)
+		if err := m.run(ctx); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		if err := migrationRepo.Record(ctx, m.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillVentSortFields gives vents posted before the top and most
+// discussed sorts existed their score and reply count.
+func backfillVentSortFields(ctx context.Context) error {
+	return ventRepo.BackfillSortFields(ctx)
+}
+
+// backfillUserAcademic gives users who signed up before they could set their
+// university and department the ones their courses and vents point to.
+func backfillUserAcademic(ctx context.Context) error {
+	return userRepo.BackfillAcademic(ctx)
+}
+
+// importSavedBy moves saves kept on vents before saves had their own
+// collection into it.
+func importSavedBy(ctx context.Context) error {
+	return saveRepo.ImportSavedBy(ctx)
+}
+
+// backfillLegacyReports gives reports filed before the moderation queue the
+// target and status it groups them by.
+func backfillLegacyReports(ctx context.Context) error {
+	return reportRepo.BackfillLegacy(ctx)
+}
+
+// backfillAppealTargetTypes gives appeals filed before the queue told content
+// and sanction appeals apart the target type of their action.
+func backfillAppealTargetTypes(ctx context.Context) error {
+	return appealRepo.BackfillTargetTypes(ctx)
+}
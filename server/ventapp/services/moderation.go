@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	moderation     = config.DefaultConfig().Moderation
+	moderationRepo = repositories.NewModerationRepository()
+)
+
+// AutoHidden describes content that was put under review automatically,
+// either after being reported or because screening held it. Priority marks
+// content that may signal a crisis. Moderators are the users who should be
+// told about it.
+type AutoHidden struct {
+	TargetType string               `json:"target_type"`
+	TargetID   primitive.ObjectID   `json:"target_id"`
+	VentID     primitive.ObjectID   `json:"vent_id"`
+	Priority   bool                 `json:"priority,omitempty"`
+	AuthorID   primitive.ObjectID   `json:"-"`
+	Moderators []primitive.ObjectID `json:"-"`
+}
+
+// reportWeight is what a report from the user counts towards auto-hiding
+// content in the given scope. Only trusted users' reports carry weight.
+func reportWeight(ctx context.Context, userID primitive.ObjectID, scope models.ResourceScope) (float64, error) {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	trusted := moderation.TrustedAccountAge > 0 && time.Since(u.CreatedAt) >= moderation.TrustedAccountAge
+	if !trusted {
+		if trusted, err = Can(ctx, userID, models.PermViewReports, scope); err != nil {
+			return 0, err
+		}
+	}
+	if !trusted {
+		return 0, nil
+	}
+	return moderation.TrustedReportWeight, nil
+}
+
+// crossesThreshold reports whether one more report of weight w, on content
+// that already had count reports weighing total, crosses an auto-hide
+// threshold. Only the report that crosses it triggers a hide, so content a
+// moderator has restored is not hidden again by later reports.
+func crossesThreshold(count int, total, w float64) bool {
+	if n := moderation.AutoHideReports; n > 0 && count <= n && count+1 > n {
+		return true
+	}
+	max := moderation.AutoHideWeight
+	return max > 0 && w > 0 && total <= max && total+w > max
+}
+
+// autoHide puts reported content under review and records it in the
+// moderation log. It returns nil if the content was already under review.
+func autoHide(ctx context.Context, targetType string, targetID, authorID primitive.ObjectID, vent *models.Vent) (*AutoHidden, error) {
+	before := contentSnapshot(ctx, targetType, targetID)
+	var err error
+	switch targetType {
+	case models.TargetVent:
+		err = ventRepo.SetUnderReview(ctx, targetID, true)
+	case models.TargetReply:
+		err = replyRepo.SetUnderReview(ctx, targetID, true)
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	action := contentAction(models.ModActionAutoHide, nil, targetType, targetID, authorID, vent, "report threshold reached")
+	action.Before = before
+	action.After = contentSnapshot(ctx, targetType, targetID)
+	logAction(ctx, action)
+
+	hidden := &AutoHidden{TargetType: targetType, TargetID: targetID, VentID: vent.ID, AuthorID: authorID}
+	if hidden.Moderators, err = Moderators(ctx, models.PermViewReports, vent.Scope()); err != nil {
+		log.Printf("moderator lookup failed for %s %s: %v", targetType, targetID.Hex(), err)
+	}
+	return hidden, nil
+}
+
+// Moderators returns the users who hold perm in the given scope, including
+// legacy admins.
+func Moderators(ctx context.Context, perm models.Permission, scope models.ResourceScope) ([]primitive.ObjectID, error) {
+	ids, err := userRepo.FindAdminIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []models.Role
+	for role := range models.RolePermissions {
+		if role.Grants(perm) {
+			roles = append(roles, role)
+		}
+	}
+	bindings, err := roleRepo.FindByRoles(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, b := range bindings {
+		if b.Covers(scope) && !seen[b.UserID] {
+			seen[b.UserID] = true
+			ids = append(ids, b.UserID)
+		}
+	}
+	return ids, nil
+}
+
+// CanSeeHidden reports whether the viewer may see content that is held for
+// review or shadowed: its author can, as can anyone who views reports in its
+// scope. viewerID is nil for anonymous viewers.
+func CanSeeHidden(ctx context.Context, viewerID *primitive.ObjectID, authorID primitive.ObjectID, scope models.ResourceScope) (bool, error) {
+	if viewerID == nil {
+		return false, nil
+	}
+	if *viewerID == authorID {
+		return true, nil
+	}
+	return Can(ctx, *viewerID, models.PermViewReports, scope)
+}
+
+// Restored describes content a moderator took off review, for telling its
+// author and the moderators who were told of the hold.
+type Restored struct {
+	TargetType string               `json:"target_type"`
+	TargetID   primitive.ObjectID   `json:"target_id"`
+	VentID     primitive.ObjectID   `json:"vent_id"`
+	AuthorID   primitive.ObjectID   `json:"-"`
+	Moderators []primitive.ObjectID `json:"-"`
+}
+
+// RestoreContent lifts the review hold on a vent or reply. It requires
+// PermModerateContent in the content's scope. Restoring content that is not
+// under review does nothing and returns a nil Restored.
+func RestoreContent(ctx context.Context, actorID primitive.ObjectID, targetType string, targetID primitive.ObjectID, note string) (*Restored, error) {
+	vent, authorID, err := reportTargetAuthor(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	lifted, err := liftReview(ctx, actorID, targetType, targetID, authorID, vent, note)
+	if err != nil || !lifted {
+		return nil, err
+	}
+	restored := &Restored{TargetType: targetType, TargetID: targetID, VentID: vent.ID, AuthorID: authorID}
+	if restored.Moderators, err = Moderators(ctx, models.PermViewReports, vent.Scope()); err != nil {
+		log.Printf("moderator lookup failed for restored %s %s: %v", targetType, targetID.Hex(), err)
+	}
+	return restored, nil
+}
+
+// liftReview clears the review hold on content and records who lifted it,
+// reporting whether there was a hold. vent is the vent the content belongs to
+// and authorID its author.
+func liftReview(ctx context.Context, actorID primitive.ObjectID, targetType string, targetID, authorID primitive.ObjectID, vent *models.Vent, note string) (bool, error) {
+	before := contentSnapshot(ctx, targetType, targetID)
+	var err error
+	switch targetType {
+	case models.TargetVent:
+		err = ventRepo.SetUnderReview(ctx, targetID, false)
+	case models.TargetReply:
+		err = replyRepo.SetUnderReview(ctx, targetID, false)
+	}
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	action := contentAction(models.ModActionRestore, &actorID, targetType, targetID, authorID, vent, note)
+	action.Before = before
+	action.After = contentSnapshot(ctx, targetType, targetID)
+	logAction(ctx, action)
+	return true, nil
+}
+
+// contentAction starts a moderation log entry for an action on a vent or
+// reply. vent is the vent the content belongs to and authorID its author.
+// actorID is nil for actions the system takes on its own.
+func contentAction(action string, actorID *primitive.ObjectID, targetType string, targetID, authorID primitive.ObjectID, vent *models.Vent, reason string) *models.ModerationAction {
+	return &models.ModerationAction{
+		Action:       action,
+		ActorID:      actorID,
+		SubjectID:    authorID,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		Reason:       reason,
+		UniversityID: vent.UniversityID,
+		DepartmentID: vent.DepartmentID,
+		CourseID:     vent.CourseID,
+	}
+}
+
+// userAction starts a moderation log entry for an action on a user account.
+func userAction(action string, actorID primitive.ObjectID, u *models.User, scope models.ResourceScope, reason string) *models.ModerationAction {
+	return &models.ModerationAction{
+		Action:       action,
+		ActorID:      &actorID,
+		SubjectID:    u.ID,
+		TargetType:   models.TargetUser,
+		TargetID:     u.ID,
+		Reason:       reason,
+		UniversityID: scope.UniversityID,
+		DepartmentID: scope.DepartmentID,
+	}
+}
+
+// logAction appends an entry to the moderation log and tells the affected
+// user. The action has already taken effect by then, so a failed write is
+// logged rather than returned.
+func logAction(ctx context.Context, a *models.ModerationAction) {
+	err := moderationRepo.Create(ctx, a)
+	if err != nil {
+		log.Printf("moderation log write failed for %s %s %s: %v", a.Action, a.TargetType, a.TargetID.Hex(), err)
+	}
+	notifyModeration(ctx, a, err == nil)
+}
+
+// snapshot converts a document to the form the moderation log stores.
+func snapshot(doc interface{}) bson.M {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		log.Printf("moderation snapshot failed: %v", err)
+		return nil
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		log.Printf("moderation snapshot failed: %v", err)
+		return nil
+	}
+	return m
+}
+
+// contentSnapshot captures the current state of a vent or reply, or nil if
+// it cannot be loaded.
+func contentSnapshot(ctx context.Context, targetType string, targetID primitive.ObjectID) bson.M {
+	switch targetType {
+	case models.TargetVent:
+		if v, err := ventRepo.FindAnyByID(ctx, targetID); err == nil {
+			return snapshot(v)
+		}
+	case models.TargetReply:
+		if rep, err := replyRepo.FindByID(ctx, targetID); err == nil {
+			return snapshot(rep)
+		}
+	}
+	return nil
+}
+
+// ModerationLog returns one page of the moderation log, limited to the
+// scopes in which the actor may view the audit trail.
+func ModerationLog(ctx context.Context, actorID primitive.ObjectID, f repositories.ModerationFilter, after *repositories.Cursor, limit int64) (models.Page[models.ModerationAction], error) {
+	global, bindings, err := BindingsWith(ctx, actorID, models.PermViewAudit)
+	if err != nil {
+		return models.Page[models.ModerationAction]{}, err
+	}
+	if !global {
+		f.Scopes = bindings
+	}
+	return moderationRepo.FindPage(ctx, f, after, limit)
+}
+
+// moderatorFields are the snapshot fields naming the moderator who acted.
+var moderatorFields = []string{"deleted_by", "issued_by", "lifted_by"}
+
+// ModerationHistory returns one page of the actions taken against the user,
+// so they can see and appeal them. Moderators' identities are left out,
+// including from the before and after snapshots.
+func ModerationHistory(ctx context.Context, userID primitive.ObjectID, after *repositories.Cursor, limit int64) (models.Page[models.ModerationAction], error) {
+	page, err := moderationRepo.FindPage(ctx, repositories.ModerationFilter{SubjectID: &userID}, after, limit)
+	if err != nil {
+		return page, err
+	}
+	for i := range page.Items {
+		a := &page.Items[i]
+		a.ActorID = nil
+		for _, field := range moderatorFields {
+			delete(a.Before, field)
+			delete(a.After, field)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	notificationRepo = repositories.NewNotificationRepository()
+	heldRepo         = repositories.NewHeldNotificationRepository()
+)
+
+// NotificationChannel delivers notifications outside the app.
+type NotificationChannel interface {
+	// Name is the channel users switch on and off in their settings, one of
+	// the models.Channel constants.
+	Name() string
+	Send(ctx context.Context, u *models.User, n models.Notification) error
+}
+
+// Notifier records notifications and hands them to the channels that
+// deliver them. Everything that tells a user about activity goes through
+// notifier.
+type Notifier struct {
+	deliverers []func(n models.Notification, unread int64)
+	channels   []*channelQueue
+}
+
+// channelQueue feeds a channel its notifications one at a time from a
+// bounded queue, so a channel that paces itself, like Telegram, holds up
+// neither the actions behind its notifications nor the other channels.
+type channelQueue struct {
+	ch    NotificationChannel
+	queue chan models.Notification
+}
+
+func (q *channelQueue) run() {
+	for n := range q.queue {
+		ctx := context.Background()
+		u, err := userRepo.FindByID(ctx, n.UserID)
+		if err == nil {
+			err = q.ch.Send(ctx, u, n)
+		}
+		if err != nil {
+			log.Printf("%s notification over %s failed for user %s: %v", n.Type, q.ch.Name(), n.UserID.Hex(), err)
+		}
+	}
+}
+
+var notifier = &Notifier{}
+
+// OnNotification registers deliver to be handed every notification as it is
+// recorded, with its user's unread count. Deliverers are registered at
+// startup, before any notification is sent.
+func OnNotification(deliver func(n models.Notification, unread int64)) {
+	notifier.deliverers = append(notifier.deliverers, deliver)
+}
+
+// RegisterChannel adds a channel notifications are sent on outside the app,
+// for users who switched it on, and starts its sender. Channels are
+// registered at startup, after Configure and before any notification is
+// sent.
+func RegisterChannel(ch NotificationChannel) {
+	q := &channelQueue{ch: ch, queue: make(chan models.Notification, notify.SendQueue)}
+	notifier.channels = append(notifier.channels, q)
+	go q.run()
+}
+
+// Notify records n for its user and delivers it. actorID is the user whose
+// activity caused it, nil for moderators and the system; users are not told
+// about their own activity or that of users they blocked or muted. Each
+// channel is used only if the user's settings switch it on for the type,
+// and what would be sent outside the app in their quiet hours is held until
+// they end. Failures are logged, so a notification never fails the action
+// behind it.
+func (nt *Notifier) Notify(ctx context.Context, n models.Notification, actorID *primitive.ObjectID) {
+	if err := nt.notify(ctx, n, actorID); err != nil {
+		log.Printf("%s notification failed for user %s: %v", n.Type, n.UserID.Hex(), err)
+	}
+}
+
+func (nt *Notifier) notify(ctx context.Context, n models.Notification, actorID *primitive.ObjectID) error {
+	if actorID != nil {
+		if *actorID == n.UserID {
+			return nil
+		}
+		excluded, err := relationRepo.Excludes(ctx, n.UserID, *actorID)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			return nil
+		}
+	}
+
+	prefs, err := notificationPrefs(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	channels := prefs.Channels(n.Type)
+	if !channels.InApp {
+		if actorID != nil {
+			n.ActorIDs = []primitive.ObjectID{*actorID}
+			n.ActorCount = 1
+		}
+		return nt.sendOrHold(ctx, prefs, n, time.Now())
+	}
+
+	saved := &n
+	if models.Grouped(n.Type) && actorID != nil {
+		saved, err = notificationRepo.Group(ctx, &n, *actorID)
+		if mongo.IsDuplicateKeyError(err) {
+			// a concurrent event created the group; retry to join it
+			saved, err = notificationRepo.Group(ctx, &n, *actorID)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		if actorID != nil {
+			n.ActorIDs = []primitive.ObjectID{*actorID}
+			n.ActorCount = 1
+		}
+		if err := notificationRepo.Create(ctx, &n); err != nil {
+			return err
+		}
+	}
+
+	unread, err := notificationRepo.CountUnread(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	for _, deliver := range nt.deliverers {
+		deliver(*saved, unread)
+	}
+	return nt.sendOrHold(ctx, prefs, *saved, time.Now())
+}
+
+// sendOrHold sends n on the channels the user switched on for it, or holds
+// it until their quiet hours end if it is now one of them.
+func (nt *Notifier) sendOrHold(ctx context.Context, prefs *models.NotificationPrefs, n models.Notification, now time.Time) error {
+	var enabled []*channelQueue
+	channels := prefs.Channels(n.Type)
+	for _, q := range nt.channels {
+		if channels.Enabled(q.ch.Name()) {
+			enabled = append(enabled, q)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+	if quiet(prefs, now) {
+		return heldRepo.Hold(ctx, n, quietUntil(prefs, now))
+	}
+	nt.send(n, enabled)
+	return nil
+}
+
+// send queues n on the channels. A channel whose queue is full drops it.
+func (nt *Notifier) send(n models.Notification, enabled []*channelQueue) {
+	for _, q := range enabled {
+		select {
+		case q.queue <- n:
+		default:
+			log.Printf("%s notification over %s dropped for user %s: send queue full", n.Type, q.ch.Name(), n.UserID.Hex())
+		}
+	}
+}
+
+// RunReleaseJob sends notifications held during quiet hours once they end,
+// checking every ReleaseInterval until ctx is cancelled.
+func RunReleaseJob(ctx context.Context) {
+	ticker := time.NewTicker(notify.ReleaseInterval)
+	defer ticker.Stop()
+	for {
+		if err := ReleaseHeldNotifications(ctx, time.Now()); err != nil {
+			log.Printf("release job failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReleaseHeldNotifications sends every held notification due by now, on the
+// channels its user has switched on since. Users who moved their quiet hours
+// meanwhile have theirs held again until the new end. Each notification is
+// taken before it is sent, so concurrent jobs never send it twice.
+func ReleaseHeldNotifications(ctx context.Context, now time.Time) error {
+	for {
+		h, err := heldRepo.TakeDue(ctx, now)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		prefs, err := notificationPrefs(ctx, h.UserID)
+		if err == nil {
+			err = notifier.sendOrHold(ctx, prefs, h.Notification, now)
+		}
+		if err != nil {
+			log.Printf("%s notification release failed for user %s: %v", h.Notification.Type, h.UserID.Hex(), err)
+		}
+	}
+}
+
+// notifyModeration tells the affected user about a moderation action taken
+// on their content or account. Shadow bans are never announced, and closing
+// reports is announced through the removal or restore it implies. logged
+// reports whether the action made it into the log, so it can be appealed.
+func notifyModeration(ctx context.Context, a *models.ModerationAction, logged bool) {
+	switch a.Action {
+	case models.ModActionAutoHide, models.ModActionHold, models.ModActionRestore,
+		models.ModActionDelete, models.ModActionUndelete,
+		models.ModActionSuspend, models.ModActionBan, models.ModActionLiftSanction:
+	default:
+		return
+	}
+
+	n := models.Notification{
+		UserID:     a.SubjectID,
+		Type:       models.NotifyModeration,
+		TargetType: a.TargetType,
+		TargetID:   a.TargetID,
+		Action:     a.Action,
+	}
+	if logged {
+		n.ActionID = &a.ID
+	}
+	switch a.TargetType {
+	case models.TargetVent:
+		n.VentID = &a.TargetID
+	case models.TargetReply:
+		if rep, err := replyRepo.FindByID(ctx, a.TargetID); err == nil {
+			n.VentID = &rep.VentID
+		}
+	}
+	notifier.Notify(ctx, n, nil)
+}
+
+// Notifications returns one page of the user's notifications, newest first,
+// with their unread count.
+func Notifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after *repositories.Cursor, limit int64) (models.NotificationPage, error) {
+	page, err := notificationRepo.FindPage(ctx, userID, unreadOnly, after, limit)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	unread, err := notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	return models.NotificationPage{Page: page, UnreadCount: unread}, nil
+}
+
+// UnreadNotifications returns how many of the user's notifications are
+// unread.
+func UnreadNotifications(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return notificationRepo.CountUnread(ctx, userID)
+}
+
+// MarkNotificationRead marks one of the user's notifications read and
+// returns how many remain unread.
+func MarkNotificationRead(ctx context.Context, userID, id primitive.ObjectID) (int64, error) {
+	if err := notificationRepo.MarkRead(ctx, id, userID); err != nil {
+		return 0, err
+	}
+	return notificationRepo.CountUnread(ctx, userID)
+}
+
+// MarkAllNotificationsRead marks every notification the user has so far
+// read and returns how many remain unread, which is more than zero only
+// when new ones arrived meanwhile.
+func MarkAllNotificationsRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	if _, err := notificationRepo.MarkAllRead(ctx, userID, time.Now()); err != nil {
+		return 0, err
+	}
+	return notificationRepo.CountUnread(ctx, userID)
+}
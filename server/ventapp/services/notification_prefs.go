@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInvalidPrefs is returned when notification settings name an unknown
+// notification type, digest frequency or time zone, or have malformed quiet
+// hours.
+var ErrInvalidPrefs = errors.New("invalid notification settings")
+
+var (
+	notify    = config.DefaultConfig().Notify
+	prefsRepo = repositories.NewNotificationPrefsRepository()
+)
+
+// NotificationSettings returns the user's notification settings with the
+// channels of every notification type filled in.
+func NotificationSettings(ctx context.Context, userID primitive.ObjectID) (*models.NotificationPrefs, error) {
+	p, err := notificationPrefs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]models.ChannelPrefs, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		types[t] = p.Channels(t)
+	}
+	p.Types = types
+	return p, nil
+}
+
+// UpdateNotificationSettings replaces the user's notification settings.
+// Types left out go back to their default channels, and an empty digest
+// frequency turns digests off.
+func UpdateNotificationSettings(ctx context.Context, userID primitive.ObjectID, p models.NotificationPrefs) (*models.NotificationPrefs, error) {
+	for t := range p.Types {
+		if !slices.Contains(models.NotificationTypes, t) {
+			return nil, fmt.Errorf("%w: unknown notification type %q", ErrInvalidPrefs, t)
+		}
+	}
+	switch p.Digest {
+	case "":
+		p.Digest = models.DigestOff
+	case models.DigestOff, models.DigestDaily, models.DigestWeekly:
+	default:
+		return nil, fmt.Errorf("%w: digest must be off, daily or weekly", ErrInvalidPrefs)
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidPrefs, p.Timezone)
+		}
+	}
+	if q := p.QuietHours; q != nil {
+		_, startErr := time.Parse("15:04", q.Start)
+		_, endErr := time.Parse("15:04", q.End)
+		if startErr != nil || endErr != nil || q.Start == q.End {
+			return nil, fmt.Errorf("%w: quiet hours need distinct HH:MM start and end times", ErrInvalidPrefs)
+		}
+	}
+	if p.Types == nil {
+		p.Types = map[string]models.ChannelPrefs{}
+	}
+
+	p.UserID = userID
+	if err := prefsRepo.Save(ctx, &p); err != nil {
+		return nil, err
+	}
+	return NotificationSettings(ctx, userID)
+}
+
+// notificationPrefs returns the user's stored settings, or the defaults if
+// they never changed them.
+func notificationPrefs(ctx context.Context, userID primitive.ObjectID) (*models.NotificationPrefs, error) {
+	p, err := prefsRepo.FindByUser(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return &models.NotificationPrefs{UserID: userID, Types: map[string]models.ChannelPrefs{}, Digest: models.DigestOff}, nil
+	}
+	return p, err
+}
+
+// prefsLocation returns the time zone the settings follow.
+func prefsLocation(p *models.NotificationPrefs) *time.Location {
+	name := p.Timezone
+	if name == "" {
+		name = notify.Timezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// quiet reports whether t falls in the user's quiet hours.
+func quiet(p *models.NotificationPrefs, t time.Time) bool {
+	return p.QuietHours != nil && p.QuietHours.Contains(t.In(prefsLocation(p)))
+}
+
+// quietUntil returns when the user's quiet hours around t end.
+func quietUntil(p *models.NotificationPrefs, t time.Time) time.Time {
+	return p.QuietHours.Until(t.In(prefsLocation(p)))
+}
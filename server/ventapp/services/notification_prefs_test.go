@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"ventapp/server/ventapp/models"
+)
+
+func TestQuietInUserTimezone(t *testing.T) {
+	overnight := &models.QuietHours{Start: "22:00", End: "07:00"}
+	tests := []struct {
+		name     string
+		timezone string
+		now      time.Time
+		quiet    bool
+		until    time.Time
+	}{
+		// the default zone, Africa/Addis_Ababa, is UTC+3
+		{"default zone before span", "", time.Date(2026, 10, 19, 18, 59, 0, 0, time.UTC), false, time.Time{}},
+		{"default zone before midnight", "", time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC), true, time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)},
+		{"default zone after midnight", "", time.Date(2026, 10, 20, 3, 59, 0, 0, time.UTC), true, time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)},
+		{"default zone span ends", "", time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"own zone", "Asia/Tokyo", time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)},
+		{"unknown zone falls back to UTC", "Nowhere/Else", time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 20, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &models.NotificationPrefs{QuietHours: overnight, Timezone: tt.timezone}
+			if got := quiet(p, tt.now); got != tt.quiet {
+				t.Fatalf("quiet(%s) = %v, want %v", tt.now, got, tt.quiet)
+			}
+			if !tt.quiet {
+				return
+			}
+			if got := quietUntil(p, tt.now); !got.Equal(tt.until) {
+				t.Errorf("quietUntil(%s) = %s, want %s", tt.now, got.UTC(), tt.until)
+			}
+		})
+	}
+
+	if quiet(&models.NotificationPrefs{}, time.Now()) {
+		t.Error("quiet without quiet hours")
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	userRepo = repositories.NewUserRepository()
+	roleRepo = repositories.NewRoleRepository()
+)
+
+// Can reports whether the user holds perm for content in the given scope.
+// Users with the legacy IsAdmin flag are treated as global admins.
+func Can(ctx context.Context, userID primitive.ObjectID, perm models.Permission, scope models.ResourceScope) (bool, error) {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if u.IsAdmin {
+		return true, nil
+	}
+
+	bindings, err := roleRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range bindings {
+		if b.Role.Grants(perm) && b.Covers(scope) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CanAnywhere reports whether the user holds perm in at least one scope. It is
+// meant for coarse route guards; handlers still narrow with Can per resource.
+func CanAnywhere(ctx context.Context, userID primitive.ObjectID, perm models.Permission) (bool, error) {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if u.IsAdmin {
+		return true, nil
+	}
+
+	bindings, err := roleRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range bindings {
+		if b.Role.Grants(perm) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// BindingsWith returns the user's role bindings that grant perm. global is
+// true when the user holds perm everywhere, in which case the bindings can be
+// ignored.
+func BindingsWith(ctx context.Context, userID primitive.ObjectID, perm models.Permission) (global bool, bindings []models.RoleBinding, err error) {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	if u.IsAdmin {
+		return true, nil, nil
+	}
+
+	all, err := roleRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+	bindings = []models.RoleBinding{}
+	for _, b := range all {
+		if !b.Role.Grants(perm) {
+			continue
+		}
+		if b.ScopeType == models.ScopeGlobal {
+			return true, nil, nil
+		}
+		bindings = append(bindings, b)
+	}
+	return false, bindings, nil
+}
+
+// BindingScope returns the scope a role binding applies to with its parents
+// filled in, so that managers of a university can manage the bindings of its
+// departments and courses. Unknown scope ids wrap ErrInvalidScope.
+func BindingScope(ctx context.Context, b models.RoleBinding) (models.ResourceScope, error) {
+	s := b.ResourceScope()
+	return ResolveScope(ctx, s.UniversityID, s.DepartmentID, s.CourseID)
+}
+
+// ScopesWith returns every university, department and course in which the
+// user holds perm, or nil if they hold it everywhere.
+func ScopesWith(ctx context.Context, userID primitive.ObjectID, perm models.Permission) (*repositories.BindingScopes, error) {
+	global, bindings, err := BindingsWith(ctx, userID, perm)
+	if err != nil || global {
+		return nil, err
+	}
+
+	s := &repositories.BindingScopes{}
+	for _, b := range bindings {
+		switch b.ScopeType {
+		case models.ScopeUniversity:
+			s.UniversityIDs = append(s.UniversityIDs, *b.ScopeID)
+		case models.ScopeDepartment:
+			s.DepartmentIDs = append(s.DepartmentIDs, *b.ScopeID)
+		case models.ScopeCourse:
+			s.CourseIDs = append(s.CourseIDs, *b.ScopeID)
+		}
+	}
+	if len(s.UniversityIDs) > 0 {
+		ids, err := departmentRepo.IDsByUniversities(ctx, s.UniversityIDs)
+		if err != nil {
+			return nil, err
+		}
+		s.DepartmentIDs = append(s.DepartmentIDs, ids...)
+	}
+	if len(s.DepartmentIDs) > 0 {
+		ids, err := courseRepo.IDsByDepartments(ctx, s.DepartmentIDs)
+		if err != nil {
+			return nil, err
+		}
+		s.CourseIDs = append(s.CourseIDs, ids...)
+	}
+	return s, nil
+}
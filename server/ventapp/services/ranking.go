@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// hotEpoch anchors the age term of the hot score. Any fixed instant works; a
+// recent one keeps the stored scores small.
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	ranking      = config.DefaultConfig().Ranking
+	ventRepo     = repositories.NewVentRepository()
+	activityRepo = repositories.NewVentActivityRepository()
+)
+
+// RefreshHotScore recomputes a vent's stored hot score, ranking it by the
+// order of magnitude of its net votes (replies count as ReplyWeight votes
+// each) plus its age over HotGravity. Call it after creating a vent and after
+// any change to its votes or reply count.
+func RefreshHotScore(ctx context.Context, ventID primitive.ObjectID) error {
+	_, err := ventRepo.RefreshHotScore(ctx, ventID, hotEpoch, ranking.HotGravity, ranking.ReplyWeight)
+	return err
+}
+
+// backfillScores gives vents posted before hot and trending sorts existed
+// their scores.
+func backfillScores(ctx context.Context) error {
+	return ventRepo.BackfillScores(ctx, hotEpoch, ranking.HotGravity, ranking.ReplyWeight)
+}
+
+// RecordVoteActivity counts a change in net votes towards the vent's trending score.
+func RecordVoteActivity(ctx context.Context, ventID primitive.ObjectID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	bucket := time.Now().Truncate(ranking.TrendingBucket)
+	return activityRepo.Increment(ctx, ventID, bucket, delta)
+}
+
+// RecomputeTrending sets every vent's trending score to its net votes per hour
+// over the trending window and prunes activity older than the window.
+func RecomputeTrending(ctx context.Context) error {
+	since := time.Now().Add(-ranking.TrendingWindow)
+	sums, err := activityRepo.SumSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	hours := ranking.TrendingWindow.Hours()
+	scores := make(map[primitive.ObjectID]float64, len(sums))
+	for id, votes := range sums {
+		if votes > 0 {
+			scores[id] = float64(votes) / hours
+		}
+	}
+	if err := ventRepo.SetTrendingScores(ctx, scores); err != nil {
+		return err
+	}
+	return activityRepo.DeleteBefore(ctx, since.Truncate(ranking.TrendingBucket))
+}
+
+// RunTrendingJob recomputes trending scores every TrendingInterval until ctx is done.
+func RunTrendingJob(ctx context.Context) {
+	ticker := time.NewTicker(ranking.TrendingInterval)
+	defer ticker.Stop()
+	for {
+		if err := RecomputeTrending(ctx); err != nil {
+			log.Printf("trending recompute failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
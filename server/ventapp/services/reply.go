@@ -0,0 +1,529 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotQuestion is returned when accepting an answer on a post that is not a question.
+var ErrNotQuestion = errors.New("only questions can have an accepted answer")
+
+// ErrInvalidAnswer is returned when the reply to accept is not a live
+// top-level reply on the question.
+var ErrInvalidAnswer = errors.New("reply cannot be accepted as an answer")
+
+// ErrInvalidParent is returned when a reply's parent is on another vent, has
+// been deleted, or nesting would exceed the maximum depth.
+var ErrInvalidParent = errors.New("invalid parent reply")
+
+var (
+	replies   = config.DefaultConfig().Replies
+	replyRepo = repositories.NewReplyRepository()
+)
+
+// CreateReply adds a reply to a vent, optionally under another reply, and
+// updates the reply counts the feed and hot score depend on. The author's
+// posting rate limit applies and the content is screened first. Replies by
+// shadow-banned authors are shadowed, and replies screening holds are saved
+// under review and queued for moderators. Authors of the vent or parent
+// reply who blocked the replier make it fail with ErrBlocked. The authors
+// replied to and the users mentioned are notified.
+func CreateReply(ctx context.Context, authorID, ventID primitive.ObjectID, parentID *primitive.ObjectID, content string) (*models.Reply, *Screening, error) {
+	shadowedUntil, err := CheckCanPost(ctx, authorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rep := &models.Reply{
+		VentID:        ventID,
+		AuthorID:      authorID,
+		ParentID:      parentID,
+		ShadowedUntil: shadowedUntil,
+	}
+	repliedTo := []primitive.ObjectID{vent.AuthorID}
+	if parentID != nil {
+		parent, err := replyRepo.FindByID(ctx, *parentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if parent.VentID != ventID || parent.IsDeleted || parent.Depth+1 > replies.MaxDepth {
+			return nil, nil, ErrInvalidParent
+		}
+		rep.Depth = parent.Depth + 1
+		repliedTo = append(repliedTo, parent.AuthorID)
+	}
+	if err := checkNotBlocked(ctx, authorID, repliedTo...); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkPostRate(ctx, authorID, models.TargetReply); err != nil {
+		return nil, nil, err
+	}
+	s, err := screen(ctx, &Screening{
+		AuthorID:   authorID,
+		TargetType: models.TargetReply,
+		VentID:     ventID,
+		Content:    content,
+		Scope:      vent.Scope(),
+	})
+	if err != nil {
+		refundPostRate(authorID, models.TargetReply)
+		return nil, nil, err
+	}
+	rep.Content = s.Content
+	rep.Simhash = s.Simhash
+	rep.UnderReview = s.Held()
+	if err := replyRepo.Create(ctx, rep); err != nil {
+		return nil, nil, err
+	}
+	if s.Held() {
+		holdForReview(ctx, s, rep.ID, vent, nil)
+	}
+
+	if parentID != nil {
+		if err := replyRepo.IncrementReplyCount(ctx, *parentID, 1); err != nil {
+			log.Printf("reply count update failed for reply %s: %v", parentID.Hex(), err)
+		}
+	}
+	if err := ventRepo.IncrementReplyCount(ctx, ventID, 1); err != nil {
+		log.Printf("reply count update failed for vent %s: %v", ventID.Hex(), err)
+	}
+	if err := RefreshHotScore(ctx, ventID); err != nil {
+		log.Printf("hot score refresh failed for vent %s: %v", ventID.Hex(), err)
+	}
+	// like the realtime event, notifications would give shadowed and held
+	// replies away
+	if shadowedUntil == nil && !s.Held() {
+		notifyReply(ctx, rep, vent, repliedTo)
+		notifyMentions(ctx, authorID, models.TargetReply, rep.ID, ventID, rep.Content, repliedTo...)
+	}
+	return rep, s, nil
+}
+
+// notifyReply tells the authors of the vent and of the parent reply, if
+// any, about a new reply. An author replied to in both places is only told
+// about the reply to their reply.
+func notifyReply(ctx context.Context, rep *models.Reply, vent *models.Vent, repliedTo []primitive.ObjectID) {
+	if rep.ParentID != nil {
+		notifier.Notify(ctx, models.Notification{
+			UserID:     repliedTo[1],
+			Type:       models.NotifyReply,
+			TargetType: models.TargetReply,
+			TargetID:   *rep.ParentID,
+			VentID:     &vent.ID,
+		}, &rep.AuthorID)
+		if repliedTo[1] == vent.AuthorID {
+			return
+		}
+	}
+	notifier.Notify(ctx, models.Notification{
+		UserID:     vent.AuthorID,
+		Type:       models.NotifyReply,
+		TargetType: models.TargetVent,
+		TargetID:   vent.ID,
+		VentID:     &vent.ID,
+	}, &rep.AuthorID)
+}
+
+// ReplyDepth clamps a requested tree depth to the configured bounds; zero
+// selects the default.
+func ReplyDepth(requested int) int {
+	if requested <= 0 {
+		return replies.DefaultViewDepth
+	}
+	if requested > replies.MaxViewDepth {
+		return replies.MaxViewDepth
+	}
+	return requested
+}
+
+// ReplyTree returns one page of the replies under parentID (top-level when
+// nil) with their descendants loaded depth-1 further levels, ChildLimit per
+// branch and at most MaxTreeNodes in all. Each node carries its own cursor
+// for loading more of its branch. An accepted answer leads the first
+// top-level page. Replies under review keep their place but their content is
+// blanked for everyone except their author and those who view reports in the
+// vent's scope; viewerID is nil for anonymous viewers. Replies by users the
+// viewer blocked or muted are left out, unless others answered them: then
+// they show as tombstones so the answers stay reachable.
+func ReplyTree(ctx context.Context, viewerID *primitive.ObjectID, ventID primitive.ObjectID, parentID *primitive.ObjectID, after *repositories.Cursor, limit int64, depth int) (models.Page[models.ReplyNode], error) {
+	viewer := replyViewer{ID: viewerID}
+	var err error
+	if viewer.Exclude, err = ViewerExclusions(ctx, viewerID); err != nil {
+		return models.Page[models.ReplyNode]{}, err
+	}
+	if viewerID != nil {
+		vent, err := ventRepo.FindAnyByID(ctx, ventID)
+		if err != nil {
+			return models.Page[models.ReplyNode]{}, err
+		}
+		if viewer.Moderator, err = Can(ctx, *viewerID, models.PermViewReports, vent.Scope()); err != nil {
+			return models.Page[models.ReplyNode]{}, err
+		}
+	}
+	return replyTree(ctx, viewer, ventID, parentID, after, limit, depth)
+}
+
+// replyViewer is who a reply tree is built for. Moderator is set when they
+// view reports in the vent's scope, which lets them read held replies.
+type replyViewer struct {
+	ID        *primitive.ObjectID
+	Exclude   repositories.Exclusions
+	Moderator bool
+}
+
+// node wraps a reply for the viewer, without its children. Replies by
+// authors the viewer excluded are only loaded when answered and show as
+// tombstones.
+func (v replyViewer) node(rep models.Reply) models.ReplyNode {
+	if slices.Contains(v.Exclude.Authors, rep.AuthorID) {
+		rep.IsDeleted = true
+	}
+	if rep.UnderReview && !v.Moderator && (v.ID == nil || *v.ID != rep.AuthorID) {
+		rep.Content = ""
+	}
+	return models.ReplyNode{Reply: rep, Children: []models.ReplyNode{}}
+}
+
+func replyTree(ctx context.Context, viewer replyViewer, ventID primitive.ObjectID, parentID *primitive.ObjectID, after *repositories.Cursor, limit int64, depth int) (models.Page[models.ReplyNode], error) {
+	page, err := replyRepo.FindChildren(ctx, viewer.ID, viewer.Exclude, ventID, parentID, after, limit)
+	if err != nil {
+		return models.Page[models.ReplyNode]{}, err
+	}
+
+	items := page.Items
+	if parentID == nil && after == nil {
+		vent, err := ventRepo.FindByID(ctx, ventID)
+		if err != nil {
+			return models.Page[models.ReplyNode]{}, err
+		}
+		if vent.AcceptedReplyID != nil {
+			accepted, err := replyRepo.FindByID(ctx, *vent.AcceptedReplyID)
+			if err != nil {
+				return models.Page[models.ReplyNode]{}, err
+			}
+			excluded := slices.Contains(viewer.Exclude.Authors, accepted.AuthorID)
+			if accepted.VisibleTo(viewer.ID) && (!excluded || accepted.ReplyCount > 0) {
+				items = append([]models.Reply{*accepted}, items...)
+			}
+		}
+	}
+
+	nodes := make([]models.ReplyNode, len(items))
+	level := make([]*models.ReplyNode, len(items))
+	for i, rep := range items {
+		nodes[i] = viewer.node(rep)
+		level[i] = &nodes[i]
+	}
+	if err := loadBranches(ctx, viewer, ventID, level, depth); err != nil {
+		return models.Page[models.ReplyNode]{}, err
+	}
+	return models.Page[models.ReplyNode]{Items: nodes, NextCursor: page.NextCursor, HasMore: page.HasMore}, nil
+}
+
+// loadBranches fills in the children of the nodes depth-1 levels down, with
+// one query per level. Once MaxTreeNodes replies are loaded, or at the last
+// level, branches are left unloaded and marked HasMore so clients can fetch
+// them with their reply as the parent.
+func loadBranches(ctx context.Context, viewer replyViewer, ventID primitive.ObjectID, level []*models.ReplyNode, depth int) error {
+	budget := replies.MaxTreeNodes
+	for ; len(level) > 0; depth-- {
+		var parents []*models.ReplyNode
+		var ids []primitive.ObjectID
+		for _, node := range level {
+			if node.Reply.ReplyCount == 0 {
+				continue
+			}
+			if depth <= 1 || budget < int(replies.ChildLimit) {
+				node.HasMore = true
+				continue
+			}
+			budget -= int(replies.ChildLimit)
+			parents = append(parents, node)
+			ids = append(ids, node.Reply.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		pages, err := replyRepo.FindChildrenOf(ctx, viewer.ID, viewer.Exclude, ventID, ids, replies.ChildLimit)
+		if err != nil {
+			return err
+		}
+
+		level = level[:0]
+		for _, node := range parents {
+			page := pages[node.Reply.ID]
+			node.Children = make([]models.ReplyNode, len(page.Items))
+			for i, rep := range page.Items {
+				node.Children[i] = viewer.node(rep)
+				level = append(level, &node.Children[i])
+			}
+			node.NextCursor = page.NextCursor
+			node.HasMore = page.HasMore
+		}
+	}
+	return nil
+}
+
+// EditReply lets the author change a reply's content within the edit window.
+// The new content is screened like a new reply.
+func EditReply(ctx context.Context, actorID, replyID primitive.ObjectID, content string) (*models.Reply, *Screening, error) {
+	rep, err := replyRepo.FindByID(ctx, replyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rep.AuthorID != actorID {
+		return nil, nil, ErrForbidden
+	}
+	if editWindow > 0 && time.Since(rep.CreatedAt) > editWindow {
+		return nil, nil, ErrEditWindowClosed
+	}
+	if _, err := CheckCanPost(ctx, actorID); err != nil {
+		return nil, nil, err
+	}
+	vent, err := ventRepo.FindAnyByID(ctx, rep.VentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := screen(ctx, &Screening{
+		AuthorID:   actorID,
+		TargetType: models.TargetReply,
+		TargetID:   rep.ID,
+		VentID:     rep.VentID,
+		Content:    content,
+		Scope:      vent.Scope(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := replyRepo.UpdateContent(ctx, rep.ID, s.Content, s.Simhash); err != nil {
+		return nil, nil, err
+	}
+	if s.Held() {
+		if err := holdEdited(ctx, s, rep.ID, vent, rep); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	edited, err := replyRepo.FindByID(ctx, rep.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return edited, s, nil
+}
+
+// DeleteReply tombstones a reply. Authors may delete their own replies;
+// anyone else needs PermModerateContent in the vent's scope, and their delete
+// is recorded in the moderation log.
+func DeleteReply(ctx context.Context, actorID, replyID primitive.ObjectID, reason string) (*models.Reply, error) {
+	rep, err := replyRepo.FindByID(ctx, replyID)
+	if err != nil {
+		return nil, err
+	}
+	var vent *models.Vent
+	moderated := rep.AuthorID != actorID
+	if moderated {
+		if vent, err = ventRepo.FindAnyByID(ctx, rep.VentID); err != nil {
+			return nil, err
+		}
+		allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+	}
+	if err := replyRepo.SoftDelete(ctx, rep.ID, actorID, reason); err != nil {
+		return nil, err
+	}
+	if rep.Accepted {
+		if err := replyRepo.SetAccepted(ctx, rep.ID, false); err != nil {
+			return nil, err
+		}
+		if err := ventRepo.SetAcceptedReply(ctx, rep.VentID, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ventRepo.IncrementReplyCount(ctx, rep.VentID, -1); err != nil {
+		log.Printf("reply count update failed for vent %s: %v", rep.VentID.Hex(), err)
+	}
+	if err := RefreshHotScore(ctx, rep.VentID); err != nil {
+		log.Printf("hot score refresh failed for vent %s: %v", rep.VentID.Hex(), err)
+	}
+
+	deleted, err := replyRepo.FindByID(ctx, rep.ID)
+	if err != nil {
+		return nil, err
+	}
+	if moderated {
+		action := contentAction(models.ModActionDelete, &actorID, models.TargetReply, rep.ID, rep.AuthorID, vent, reason)
+		action.Before = snapshot(rep)
+		action.After = snapshot(deleted)
+		logAction(ctx, action)
+	}
+	return deleted, nil
+}
+
+// RestoreReply undoes a soft delete. It requires PermModerateContent in the
+// vent's scope. An accepted answer that was deleted is not accepted again.
+func RestoreReply(ctx context.Context, actorID, replyID primitive.ObjectID, reason string) (*models.Reply, error) {
+	rep, err := replyRepo.FindByID(ctx, replyID)
+	if err != nil {
+		return nil, err
+	}
+	vent, err := ventRepo.FindAnyByID(ctx, rep.VentID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	if err := replyRepo.Restore(ctx, rep.ID); err != nil {
+		return nil, err
+	}
+
+	if err := ventRepo.IncrementReplyCount(ctx, rep.VentID, 1); err != nil {
+		log.Printf("reply count update failed for vent %s: %v", rep.VentID.Hex(), err)
+	}
+	if err := RefreshHotScore(ctx, rep.VentID); err != nil {
+		log.Printf("hot score refresh failed for vent %s: %v", rep.VentID.Hex(), err)
+	}
+
+	restored, err := replyRepo.FindByID(ctx, rep.ID)
+	if err != nil {
+		return nil, err
+	}
+	action := contentAction(models.ModActionUndelete, &actorID, models.TargetReply, rep.ID, rep.AuthorID, vent, reason)
+	action.Before = snapshot(rep)
+	action.After = snapshot(restored)
+	logAction(ctx, action)
+	return restored, nil
+}
+
+// VoteReply sets the user's vote on a reply, like VoteVent does for vents.
+func VoteReply(ctx context.Context, userID, replyID primitive.ObjectID, value int) (*models.Reply, error) {
+	up, down, err := setVote(ctx, userID, models.TargetReply, replyID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if up != 0 || down != 0 {
+		if err := replyRepo.ApplyVoteDelta(ctx, replyID, up, down); err != nil {
+			log.Printf("vote counter update failed for reply %s, recounting: %v", replyID.Hex(), err)
+			if err := ReconcileReplyVotes(ctx, replyID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rep, err := replyRepo.FindByID(ctx, replyID)
+	if err != nil {
+		return nil, err
+	}
+	if up > 0 {
+		notifier.Notify(ctx, models.Notification{
+			UserID:     rep.AuthorID,
+			Type:       models.NotifyVote,
+			TargetType: models.TargetReply,
+			TargetID:   rep.ID,
+			VentID:     &rep.VentID,
+		}, &userID)
+	}
+	return rep, nil
+}
+
+// ReconcileReplyVotes recounts a reply's votes from the votes collection.
+func ReconcileReplyVotes(ctx context.Context, replyID primitive.ObjectID) error {
+	up, down, err := voteRepo.Count(ctx, models.TargetReply, replyID)
+	if err != nil {
+		return err
+	}
+	return replyRepo.SetVoteCounts(ctx, replyID, up, down)
+}
+
+// AcceptAnswer marks a top-level reply as the accepted answer to a question,
+// replacing any previously accepted one. Only the question's author may do so.
+// The reply's author is notified.
+func AcceptAnswer(ctx context.Context, actorID, ventID, replyID primitive.ObjectID) (*models.Reply, error) {
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	if vent.Kind != models.KindQuestion {
+		return nil, ErrNotQuestion
+	}
+	if vent.AuthorID != actorID {
+		return nil, ErrForbidden
+	}
+
+	rep, err := replyRepo.FindByID(ctx, replyID)
+	if err != nil {
+		return nil, err
+	}
+	if rep.VentID != ventID || rep.ParentID != nil || rep.IsDeleted {
+		return nil, ErrInvalidAnswer
+	}
+
+	if vent.AcceptedReplyID != nil && *vent.AcceptedReplyID != replyID {
+		if err := replyRepo.SetAccepted(ctx, *vent.AcceptedReplyID, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := replyRepo.SetAccepted(ctx, replyID, true); err != nil {
+		return nil, err
+	}
+	if err := ventRepo.SetAcceptedReply(ctx, ventID, &replyID); err != nil {
+		return nil, err
+	}
+	if !rep.Accepted {
+		notifier.Notify(ctx, models.Notification{
+			UserID:     rep.AuthorID,
+			Type:       models.NotifyAnswerAccepted,
+			TargetType: models.TargetReply,
+			TargetID:   rep.ID,
+			VentID:     &ventID,
+		}, &actorID)
+	}
+	rep.Accepted = true
+	return rep, nil
+}
+
+// ClearAcceptedAnswer removes a question's accepted answer.
+func ClearAcceptedAnswer(ctx context.Context, actorID, ventID primitive.ObjectID) (*models.Vent, error) {
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	if vent.AuthorID != actorID {
+		return nil, ErrForbidden
+	}
+	if vent.AcceptedReplyID == nil {
+		return vent, nil
+	}
+	if err := replyRepo.SetAccepted(ctx, *vent.AcceptedReplyID, false); err != nil {
+		return nil, err
+	}
+	if err := ventRepo.SetAcceptedReply(ctx, ventID, nil); err != nil {
+		return nil, err
+	}
+	vent.AcceptedReplyID = nil
+	return vent, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrAlreadyReported is returned when a user reports the same target twice.
+var ErrAlreadyReported = errors.New("you have already reported this")
+
+var reportRepo = repositories.NewReportRepository()
+
+// reportTarget resolves a report target to the vent it belongs to.
+func reportTarget(ctx context.Context, targetType string, targetID primitive.ObjectID) (*models.Vent, error) {
+	vent, _, err := reportTargetAuthor(ctx, targetType, targetID)
+	return vent, err
+}
+
+// reportTargetAuthor resolves a report target to the vent it belongs to and
+// the target's author.
+func reportTargetAuthor(ctx context.Context, targetType string, targetID primitive.ObjectID) (*models.Vent, primitive.ObjectID, error) {
+	vent, authorID, _, err := resolveReportTarget(ctx, targetType, targetID)
+	return vent, authorID, err
+}
+
+// resolveReportTarget is reportTargetAuthor that also reports whether the
+// target itself is deleted. A deleted reply stays as a tombstone under a live
+// vent.
+func resolveReportTarget(ctx context.Context, targetType string, targetID primitive.ObjectID) (*models.Vent, primitive.ObjectID, bool, error) {
+	switch targetType {
+	case models.TargetVent:
+		vent, err := ventRepo.FindAnyByID(ctx, targetID)
+		if err != nil {
+			return nil, primitive.NilObjectID, false, err
+		}
+		return vent, vent.AuthorID, vent.IsDeleted, nil
+	case models.TargetReply:
+		rep, err := replyRepo.FindByID(ctx, targetID)
+		if err != nil {
+			return nil, primitive.NilObjectID, false, err
+		}
+		vent, err := ventRepo.FindAnyByID(ctx, rep.VentID)
+		if err != nil {
+			return nil, primitive.NilObjectID, false, err
+		}
+		return vent, rep.AuthorID, rep.IsDeleted || vent.IsDeleted, nil
+	}
+	return nil, primitive.NilObjectID, false, mongo.ErrNoDocuments
+}
+
+// FileReport records a user's report of a vent or reply. Each user may
+// report a target once. When the report pushes the target over an auto-hide
+// threshold the target is put under review and described by the returned
+// AutoHidden; otherwise that is nil.
+func FileReport(ctx context.Context, reporterID primitive.ObjectID, targetType string, targetID primitive.ObjectID, reason, details string) (*models.Report, *AutoHidden, error) {
+	vent, authorID, deleted, err := resolveReportTarget(ctx, targetType, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deleted {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+	weight, err := reportWeight(ctx, reporterID, vent.Scope())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rep := &models.Report{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		VentID:       vent.ID,
+		Reporter:     reporterID,
+		Reason:       reason,
+		Details:      details,
+		Weight:       weight,
+		UniversityID: vent.UniversityID,
+		DepartmentID: vent.DepartmentID,
+		CourseID:     vent.CourseID,
+	}
+	if err := reportRepo.Create(ctx, rep); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil, ErrAlreadyReported
+		}
+		return nil, nil, err
+	}
+
+	var count int
+	var total float64
+	switch targetType {
+	case models.TargetVent:
+		var before *models.Vent
+		if before, err = ventRepo.AddReport(ctx, targetID, weight); err == nil {
+			count, total = before.ReportCount, before.ReportWeight
+		}
+	case models.TargetReply:
+		var before *models.Reply
+		if before, err = replyRepo.AddReport(ctx, targetID, weight); err == nil {
+			count, total = before.ReportCount, before.ReportWeight
+		}
+	}
+	if err != nil {
+		log.Printf("report count update failed for %s %s: %v", targetType, targetID.Hex(), err)
+		return rep, nil, nil
+	}
+	if !crossesThreshold(count, total, weight) {
+		return rep, nil, nil
+	}
+
+	hidden, err := autoHide(ctx, targetType, targetID, authorID, vent)
+	if err != nil {
+		log.Printf("auto-hide failed for %s %s: %v", targetType, targetID.Hex(), err)
+	}
+	return rep, hidden, nil
+}
+
+// ReportQueue returns one page of reports grouped by target, limited to the
+// scopes in which the actor may view reports.
+func ReportQueue(ctx context.Context, actorID primitive.ObjectID, f repositories.ReportFilter, after *repositories.Cursor, limit int64) (models.Page[models.ReportGroup], error) {
+	global, bindings, err := BindingsWith(ctx, actorID, models.PermViewReports)
+	if err != nil {
+		return models.Page[models.ReportGroup]{}, err
+	}
+	if !global {
+		f.Scopes = bindings
+	}
+	return reportRepo.FindGroups(ctx, f, after, limit)
+}
+
+// TargetReports returns every report on one target for a moderator.
+func TargetReports(ctx context.Context, actorID primitive.ObjectID, targetType string, targetID primitive.ObjectID) ([]models.Report, error) {
+	vent, err := reportTarget(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermViewReports, vent.Scope())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return reportRepo.FindByTarget(ctx, targetType, targetID)
+}
+
+// ResolveReports closes the open reports on a target. With removeContent the
+// target is soft-deleted as well and the outcome recorded as content removed;
+// otherwise any review hold on the target is lifted.
+func ResolveReports(ctx context.Context, actorID primitive.ObjectID, targetType string, targetID primitive.ObjectID, removeContent bool, note string) (int64, error) {
+	vent, authorID, err := reportTargetAuthor(ctx, targetType, targetID)
+	if err != nil {
+		return 0, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermResolveReports, vent.Scope())
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, ErrForbidden
+	}
+
+	outcome := models.OutcomeNoAction
+	if removeContent {
+		outcome = models.OutcomeContentRemoved
+		switch targetType {
+		case models.TargetVent:
+			_, err = DeleteVent(ctx, actorID, targetID, note)
+		case models.TargetReply:
+			_, err = DeleteReply(ctx, actorID, targetID, note)
+		}
+		// content that is already gone still lets the reports be closed
+		if err != nil && err != mongo.ErrNoDocuments {
+			return 0, err
+		}
+	} else if _, err := liftReview(ctx, actorID, targetType, targetID, authorID, vent, note); err != nil {
+		return 0, err
+	}
+
+	closed, err := reportRepo.CloseOpen(ctx, targetType, targetID, models.ReportResolved, actorID, outcome, note)
+	if err != nil {
+		return 0, err
+	}
+	logAction(ctx, contentAction(models.ModActionResolveReports, &actorID, targetType, targetID, authorID, vent, note))
+	return closed, nil
+}
+
+// DismissReports closes the open reports on a target without acting on it,
+// lifting any review hold.
+func DismissReports(ctx context.Context, actorID primitive.ObjectID, targetType string, targetID primitive.ObjectID, note string) (int64, error) {
+	vent, authorID, err := reportTargetAuthor(ctx, targetType, targetID)
+	if err != nil {
+		return 0, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermResolveReports, vent.Scope())
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, ErrForbidden
+	}
+	if _, err := liftReview(ctx, actorID, targetType, targetID, authorID, vent, note); err != nil {
+		return 0, err
+	}
+
+	closed, err := reportRepo.CloseOpen(ctx, targetType, targetID, models.ReportDismissed, actorID, models.OutcomeDismissed, note)
+	if err != nil {
+		return 0, err
+	}
+	logAction(ctx, contentAction(models.ModActionDismissReports, &actorID, targetType, targetID, authorID, vent, note))
+	return closed, nil
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrBanned is returned when a banned user tries to use their account.
+	ErrBanned = errors.New("account banned")
+	// ErrSuspended is returned when a suspended user tries to post.
+	ErrSuspended = errors.New("account suspended")
+	// ErrInvalidSanction is returned for a sanction that cannot be issued as
+	// requested, e.g. a suspension without an expiry.
+	ErrInvalidSanction = errors.New("invalid sanction")
+)
+
+var sanctionRepo = repositories.NewSanctionRepository()
+
+// Standing is a user's active sanctions, one per kind. Nil fields mean no
+// sanction of that kind is in force; when several overlap, the one that runs
+// longest is kept.
+type Standing struct {
+	Ban        *models.Sanction
+	Suspension *models.Sanction
+	ShadowBan  *models.Sanction
+}
+
+// UserStanding looks up the sanctions currently in force against the user.
+func UserStanding(ctx context.Context, userID primitive.ObjectID) (Standing, error) {
+	active, err := sanctionRepo.FindActive(ctx, userID, time.Now())
+	if err != nil {
+		return Standing{}, err
+	}
+	var st Standing
+	for i := range active {
+		s := &active[i]
+		switch s.Kind {
+		case models.SanctionBan:
+			st.Ban = longer(st.Ban, s)
+		case models.SanctionSuspension:
+			st.Suspension = longer(st.Suspension, s)
+		case models.SanctionShadowBan:
+			st.ShadowBan = longer(st.ShadowBan, s)
+		}
+	}
+	return st, nil
+}
+
+func longer(a, b *models.Sanction) *models.Sanction {
+	switch {
+	case a == nil:
+		return b
+	case a.ExpiresAt == nil:
+		return a
+	case b.ExpiresAt == nil || b.ExpiresAt.After(*a.ExpiresAt):
+		return b
+	}
+	return a
+}
+
+// sanctionError wraps err with when the sanction ends.
+func sanctionError(err error, s *models.Sanction) error {
+	if s.ExpiresAt == nil {
+		return err
+	}
+	return fmt.Errorf("%w until %s", err, s.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// shadowedForever stands in for the end of a permanent shadow-ban.
+var shadowedForever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// ShadowedUntil is when the user's content becomes visible to others again,
+// or nil if they are not shadow-banned.
+func (st Standing) ShadowedUntil() *time.Time {
+	switch {
+	case st.ShadowBan == nil:
+		return nil
+	case st.ShadowBan.ExpiresAt == nil:
+		return &shadowedForever
+	}
+	return st.ShadowBan.ExpiresAt
+}
+
+// CheckCanPost returns ErrBanned or ErrSuspended when the user may not write
+// content. Otherwise it returns until when what they write should be
+// shadowed, which is nil unless they are shadow-banned.
+func CheckCanPost(ctx context.Context, userID primitive.ObjectID) (*time.Time, error) {
+	st, err := UserStanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if st.Ban != nil {
+		return nil, sanctionError(ErrBanned, st.Ban)
+	}
+	if st.Suspension != nil {
+		return nil, sanctionError(ErrSuspended, st.Suspension)
+	}
+	return st.ShadowedUntil(), nil
+}
+
+// userScope is where a user sits in the academic hierarchy, for checking
+// whether an actor may manage them: the department they study in, or else
+// the department of the first course they are enrolled in that still exists.
+func userScope(ctx context.Context, u *models.User) (models.ResourceScope, error) {
+	scope := models.ResourceScope{UniversityID: u.UniversityID, DepartmentID: u.DepartmentID}
+	if u.DepartmentID != nil {
+		return scope, nil
+	}
+	for _, courseID := range u.CourseIDs {
+		enrolled, err := ResolveScope(ctx, nil, nil, &courseID)
+		if errors.Is(err, ErrInvalidScope) {
+			continue
+		}
+		if err != nil {
+			return scope, err
+		}
+		if u.UniversityID == nil || *u.UniversityID == *enrolled.UniversityID {
+			enrolled.CourseID = nil
+			return enrolled, nil
+		}
+	}
+	return scope, nil
+}
+
+// canManageUser reports whether the actor holds PermManageUsers in the
+// user's scope, which it also returns for the moderation log.
+func canManageUser(ctx context.Context, actorID primitive.ObjectID, u *models.User) (models.ResourceScope, bool, error) {
+	scope, err := userScope(ctx, u)
+	if err != nil {
+		return scope, false, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermManageUsers, scope)
+	return scope, allowed, err
+}
+
+var sanctionActions = map[string]string{
+	models.SanctionSuspension: models.ModActionSuspend,
+	models.SanctionBan:        models.ModActionBan,
+	models.SanctionShadowBan:  models.ModActionShadowBan,
+}
+
+// IssueSanction sanctions a user. It requires PermManageUsers in the user's
+// scope. Suspensions must expire; bans and shadow-bans may be permanent. A
+// shadow-ban also shadows everything the user has already posted.
+func IssueSanction(ctx context.Context, actorID, userID primitive.ObjectID, kind, reason string, expiresAt *time.Time) (*models.Sanction, error) {
+	if !models.ValidSanctionKind(kind) {
+		return nil, ErrInvalidSanction
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry is in the past", ErrInvalidSanction)
+	}
+	if kind == models.SanctionSuspension && expiresAt == nil {
+		return nil, fmt.Errorf("%w: suspensions need an expiry", ErrInvalidSanction)
+	}
+	if actorID == userID {
+		return nil, ErrForbidden
+	}
+
+	u, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	scope, allowed, err := canManageUser(ctx, actorID, u)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	s := &models.Sanction{
+		UserID:    userID,
+		Kind:      kind,
+		Reason:    reason,
+		IssuedBy:  actorID,
+		ExpiresAt: expiresAt,
+	}
+	if err := sanctionRepo.Create(ctx, s); err != nil {
+		return nil, err
+	}
+	if kind == models.SanctionShadowBan {
+		if err := syncShadow(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	action := userAction(sanctionActions[kind], actorID, u, scope, reason)
+	action.SanctionID = &s.ID
+	action.After = snapshot(s)
+	logAction(ctx, action)
+	return s, nil
+}
+
+// LiftSanction ends a sanction early. It requires PermManageUsers in the
+// sanctioned user's scope. Lifting the last shadow-ban on a user makes their
+// content visible again straight away.
+func LiftSanction(ctx context.Context, actorID, sanctionID primitive.ObjectID, reason string) (*models.Sanction, error) {
+	s, err := sanctionRepo.FindByID(ctx, sanctionID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := userRepo.FindByID(ctx, s.UserID)
+	if err != nil {
+		return nil, err
+	}
+	scope, allowed, err := canManageUser(ctx, actorID, u)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	if err := sanctionRepo.Lift(ctx, s.ID, actorID, reason); err != nil {
+		return nil, err
+	}
+	if s.Kind == models.SanctionShadowBan {
+		if err := syncShadow(ctx, s.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	lifted, err := sanctionRepo.FindByID(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	action := userAction(models.ModActionLiftSanction, actorID, u, scope, reason)
+	action.SanctionID = &s.ID
+	action.Before = snapshot(s)
+	action.After = snapshot(lifted)
+	logAction(ctx, action)
+	return lifted, nil
+}
+
+// UserSanctions returns a user's sanction history. It requires
+// PermManageUsers in the user's scope.
+func UserSanctions(ctx context.Context, actorID, userID primitive.ObjectID) ([]models.Sanction, error) {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, allowed, err := canManageUser(ctx, actorID, u)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return sanctionRepo.FindByUser(ctx, userID)
+}
+
+// syncShadow shadows everything the user has posted until their longest
+// running shadow-ban ends, or unshadows it if none is left. Expiry needs no
+// further write: content is visible again once shadowed_until has passed.
+func syncShadow(ctx context.Context, userID primitive.ObjectID) error {
+	st, err := UserStanding(ctx, userID)
+	if err != nil {
+		return err
+	}
+	until := st.ShadowedUntil()
+	if err := ventRepo.ShadowByAuthor(ctx, userID, until); err != nil {
+		return err
+	}
+	return replyRepo.ShadowByAuthor(ctx, userID, until)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var saveRepo = repositories.NewSaveRepository()
+
+// SaveVent bookmarks a vent for the user in the given folder ("" for none).
+// Saving an already saved vent just moves it to the folder.
+func SaveVent(ctx context.Context, userID, ventID primitive.ObjectID, folder string) error {
+	if _, err := ventRepo.FindByID(ctx, ventID); err != nil {
+		return err
+	}
+	created, err := saveRepo.Upsert(ctx, userID, ventID, folder)
+	if err != nil {
+		return err
+	}
+	if created {
+		if err := ventRepo.IncrementSaveCount(ctx, ventID, 1); err != nil {
+			log.Printf("save count update failed for vent %s: %v", ventID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// UnsaveVent removes the user's bookmark of a vent, if any.
+func UnsaveVent(ctx context.Context, userID, ventID primitive.ObjectID) error {
+	removed, err := saveRepo.Delete(ctx, userID, ventID)
+	if err != nil {
+		return err
+	}
+	if removed {
+		if err := ventRepo.IncrementSaveCount(ctx, ventID, -1); err != nil {
+			log.Printf("save count update failed for vent %s: %v", ventID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// IsSaved reports whether the user has bookmarked the vent.
+func IsSaved(ctx context.Context, userID, ventID primitive.ObjectID) (bool, error) {
+	return saveRepo.Exists(ctx, userID, ventID)
+}
+
+// SavedVents returns one page of the user's saved list, newest first. Saves
+// of vents that have since been deleted are skipped.
+func SavedVents(ctx context.Context, userID primitive.ObjectID, folder *string, after *repositories.Cursor, limit int64) (models.Page[models.SavedVent], error) {
+	page, err := saveRepo.FindPage(ctx, userID, folder, after, limit)
+	if err != nil {
+		return models.Page[models.SavedVent]{}, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(page.Items))
+	for _, s := range page.Items {
+		ids = append(ids, s.VentID)
+	}
+	vents, err := ventRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return models.Page[models.SavedVent]{}, err
+	}
+
+	items := make([]models.SavedVent, 0, len(page.Items))
+	for _, s := range page.Items {
+		if v, ok := vents[s.VentID]; ok {
+			items = append(items, models.SavedVent{Vent: v, Folder: s.Folder, SavedAt: s.CreatedAt})
+		}
+	}
+	return models.Page[models.SavedVent]{Items: items, NextCursor: page.NextCursor, HasMore: page.HasMore}, nil
+}
+
+// SaveFolders lists the user's folders with how many saves each holds.
+func SaveFolders(ctx context.Context, userID primitive.ObjectID) ([]models.SaveFolder, error) {
+	return saveRepo.Folders(ctx, userID)
+}
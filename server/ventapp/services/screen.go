@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"ventapp/server/ventapp/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrContentRejected is matched by the RejectedError returned when screening
+// refuses content outright.
+var ErrContentRejected = errors.New("content rejected")
+
+// RejectedError names the rules that rejected content. Support is set when
+// the content also signalled a crisis, so the author is offered help even
+// though nothing was posted.
+type RejectedError struct {
+	Rules   []string
+	Support *CrisisSupport
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrContentRejected, strings.Join(e.Rules, ", "))
+}
+
+func (e *RejectedError) Is(target error) bool { return target == ErrContentRejected }
+
+// Screening carries a vent or reply through the screening pipeline before it
+// is saved. TargetID is zero for new content, and VentID is the vent a reply
+// belongs to. Stages may rewrite Content and
+// raise Action; Reasons explains the verdict to the author and moderators.
+// Simhash fingerprints the content for duplicate detection. Priority puts
+// held content at the front of the moderation queue, and Support is shown to
+// an author who may be in crisis. Review is set once held content has been
+// saved and queued for moderators.
+type Screening struct {
+	AuthorID   primitive.ObjectID   `json:"-"`
+	TargetType string               `json:"-"`
+	TargetID   primitive.ObjectID   `json:"-"`
+	VentID     primitive.ObjectID   `json:"-"`
+	Content    string               `json:"-"`
+	Tags       []string             `json:"-"`
+	Scope      models.ResourceScope `json:"-"`
+	Action     string               `json:"action,omitempty"`
+	Reasons    []string             `json:"reasons,omitempty"`
+	Simhash    int64                `json:"-"`
+	Priority   bool                 `json:"-"`
+	Support    *CrisisSupport       `json:"-"`
+	Review     *AutoHidden          `json:"-"`
+
+	rejectedBy []string
+}
+
+// Held reports whether the content must wait for a moderator.
+func (s *Screening) Held() bool {
+	return s.Action == models.FilterHold
+}
+
+// flag records a stage's verdict, keeping the most severe action seen.
+func (s *Screening) flag(action, reason string) {
+	s.Action = severest(s.Action, action)
+	s.Reasons = append(s.Reasons, reason)
+	if action == models.FilterReject {
+		s.rejectedBy = append(s.rejectedBy, reason)
+	}
+}
+
+// ContentStage is one step of the screening pipeline.
+type ContentStage interface {
+	Screen(ctx context.Context, s *Screening) error
+}
+
+// pipeline is run in order on every new or edited vent and reply. Crisis and
+// spam detection run first so they see what the author wrote, not a masked
+// copy.
+var pipeline = []ContentStage{crisisStage{}, spamStage{}, filterStage{}}
+
+// filterStage applies the admin-configured content filter rules to the
+// content and its tags. A tag the rules would mask is dropped, since a
+// masked tag names nothing.
+type filterStage struct{}
+
+func (filterStage) Screen(ctx context.Context, s *Screening) error {
+	res := Filters.Check(s.Content)
+	s.Content = res.Content
+	for _, m := range res.Matches {
+		s.flag(m.Action, m.Rule)
+	}
+
+	tags := make([]string, 0, len(s.Tags))
+	for _, tag := range s.Tags {
+		res := Filters.CheckTag(tag)
+		for _, m := range res.Matches {
+			s.flag(m.Action, m.Rule+" in tags")
+		}
+		if res.Content == tag {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) < len(s.Tags) {
+		s.Tags = tags
+	}
+	return nil
+}
+
+// screen runs content through the pipeline and returns a RejectedError if
+// any stage rejected it.
+func screen(ctx context.Context, s *Screening) (*Screening, error) {
+	for _, stage := range pipeline {
+		if err := stage.Screen(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	if s.Action == models.FilterReject {
+		return s, &RejectedError{Rules: s.rejectedBy, Support: s.Support}
+	}
+	return s, nil
+}
+
+// holdForReview queues content that screening held for moderators: it files
+// the system's report on the content, records the hold in the moderation log
+// and sets s.Review. The content must already be under review. vent is the
+// vent the content belongs to; before is the content's prior state, nil for
+// new content.
+func holdForReview(ctx context.Context, s *Screening, targetID primitive.ObjectID, vent *models.Vent, before interface{}) {
+	reason := "held by screening: " + strings.Join(s.Reasons, ", ")
+	rep := &models.Report{
+		TargetType:   s.TargetType,
+		TargetID:     targetID,
+		VentID:       vent.ID,
+		Reason:       models.ReportFiltered,
+		Details:      reason,
+		Priority:     s.Priority,
+		UniversityID: vent.UniversityID,
+		DepartmentID: vent.DepartmentID,
+		CourseID:     vent.CourseID,
+	}
+	if s.Priority {
+		rep.Reason = models.ReportSelfHarm
+	}
+	if err := reportRepo.OpenSystem(ctx, rep); err != nil {
+		log.Printf("system report failed for %s %s: %v", s.TargetType, targetID.Hex(), err)
+	}
+
+	action := contentAction(models.ModActionHold, nil, s.TargetType, targetID, s.AuthorID, vent, reason)
+	if before != nil {
+		action.Before = snapshot(before)
+	}
+	action.After = contentSnapshot(ctx, s.TargetType, targetID)
+	logAction(ctx, action)
+
+	s.Review = &AutoHidden{TargetType: s.TargetType, TargetID: targetID, VentID: vent.ID, AuthorID: s.AuthorID, Priority: s.Priority}
+	var err error
+	if s.Review.Moderators, err = Moderators(ctx, models.PermViewReports, vent.Scope()); err != nil {
+		log.Printf("moderator lookup failed for %s %s: %v", s.TargetType, targetID.Hex(), err)
+	}
+}
+
+// holdEdited puts edited content that screening held back under review and
+// queues it. Content that was already under review is queued again so the
+// new reasons reach moderators.
+func holdEdited(ctx context.Context, s *Screening, targetID primitive.ObjectID, vent *models.Vent, before interface{}) error {
+	var err error
+	switch s.TargetType {
+	case models.TargetVent:
+		err = ventRepo.SetUnderReview(ctx, targetID, true)
+	case models.TargetReply:
+		err = replyRepo.SetUnderReview(ctx, targetID, true)
+	}
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	holdForReview(ctx, s, targetID, vent, before)
+	return nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidQuery is returned when a search query has nothing to match,
+// e.g. when every word in it is excluded.
+var ErrInvalidQuery = errors.New("search query needs at least one word or phrase to match")
+
+var search = config.DefaultConfig().Search
+
+const (
+	// snippetLength is the most runes of content a search snippet shows.
+	snippetLength = 160
+	// snippetLead is how many runes of context precede the first match.
+	snippetLead = 40
+)
+
+// Search returns one page of vents and replies matching the filter, best
+// first. targetType limits results to vents or replies; empty searches both.
+// Each hit carries a snippet with its matched terms highlighted. What the
+// viewer blocked, muted or hid is left out; viewerID is nil for anonymous
+// viewers.
+func Search(ctx context.Context, viewerID *primitive.ObjectID, f repositories.SearchFilter, targetType string, after *repositories.Cursor, limit int64) (models.Page[models.SearchHit], error) {
+	terms := searchTerms(f.Query)
+	if len(terms) == 0 {
+		return models.Page[models.SearchHit]{}, ErrInvalidQuery
+	}
+	var err error
+	if f.Exclude, err = ViewerExclusions(ctx, viewerID); err != nil {
+		return models.Page[models.SearchHit]{}, err
+	}
+	if f.Tag != "" {
+		if f.Tag, err = NormaliseTag(ctx, f.Tag); err != nil {
+			return models.Page[models.SearchHit]{}, err
+		}
+	}
+	if limit <= 0 {
+		limit = repositories.DefaultPageLimit
+	}
+	if limit > repositories.MaxPageLimit {
+		limit = repositories.MaxPageLimit
+	}
+
+	var pages []models.Page[models.SearchHit]
+	if targetType != models.TargetReply {
+		page, err := ventRepo.Search(ctx, f, hotEpoch, search.RecencyGravity, after, limit)
+		if err != nil {
+			return models.Page[models.SearchHit]{}, err
+		}
+		pages = append(pages, page)
+	}
+	if targetType != models.TargetVent {
+		page, err := replyRepo.Search(ctx, f, hotEpoch, search.RecencyGravity, after, limit)
+		if err != nil {
+			return models.Page[models.SearchHit]{}, err
+		}
+		pages = append(pages, page)
+	}
+
+	// each source returned its own best hits after the cursor, so the best
+	// of both are the best overall
+	hits := []models.SearchHit{}
+	hasMore := false
+	for _, p := range pages {
+		hits = append(hits, p.Items...)
+		hasMore = hasMore || p.HasMore
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) > 0
+	})
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+		hasMore = true
+	}
+
+	for i := range hits {
+		hits[i].Snippet, hits[i].Highlights = snippet(hits[i].Content, terms)
+	}
+	page := models.Page[models.SearchHit]{Items: hits, HasMore: hasMore}
+	if hasMore && len(hits) > 0 {
+		last := hits[len(hits)-1]
+		page.NextCursor = repositories.Cursor{Value: last.Rank, CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
+
+// searchTerms returns the words and quoted phrases a query looks for,
+// leaving out excluded ones, folded for matching.
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	add := func(s string, excluded bool) {
+		if excluded {
+			return
+		}
+		if folded := foldTerm(strings.Join(strings.Fields(s), " ")); len(folded) > 0 {
+			terms = append(terms, folded)
+		}
+	}
+
+	rest := query
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return terms
+		}
+		excluded := strings.HasPrefix(rest, "-")
+		if excluded {
+			rest = rest[1:]
+		}
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				add(rest[1:], excluded)
+				return terms
+			}
+			add(rest[1:end+1], excluded)
+			rest = rest[end+2:]
+			continue
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		for _, word := range strings.FieldsFunc(rest[:end], func(r rune) bool { return !isWordRune(r) }) {
+			add(word, excluded)
+		}
+		rest = rest[end:]
+	}
+}
+
+// snippet cuts an excerpt of content around the first match of any term and
+// returns it with the rune offsets of the matches inside it.
+func snippet(content string, terms [][]rune) (string, [][2]int) {
+	text := []rune(content)
+	spans := matchTerms(text, terms)
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	start := 0
+	if len(spans) > 0 && spans[0][0] > snippetLead {
+		start = spans[0][0] - snippetLead
+		// start on a word rather than inside one
+		for start < spans[0][0] && !unicode.IsSpace(text[start-1]) {
+			start++
+		}
+	}
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		for end > start && !unicode.IsSpace(text[end]) {
+			end--
+		}
+		if end == start {
+			end = start + snippetLength
+		}
+	}
+
+	var b strings.Builder
+	offset := -start
+	if start > 0 {
+		b.WriteString("…")
+		offset++
+	}
+	b.WriteString(string(text[start:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	highlights := [][2]int{}
+	for _, s := range spans {
+		if s[0] >= start && s[1] <= end {
+			highlights = append(highlights, [2]int{s[0] + offset, s[1] + offset})
+		}
+	}
+	return b.String(), highlights
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrRateLimited is matched by the RateLimitError returned when a user posts
+// faster than their limit allows.
+var ErrRateLimited = errors.New("posting too fast")
+
+// RateLimitError says how long a user must wait before posting again.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("you are posting too fast; try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+var spam = config.DefaultConfig().Spam
+
+// shingleSize is how many consecutive words make up one shingle.
+const shingleSize = 3
+
+// postLimiter keeps one token bucket per user and kind of post, in memory.
+// Limits therefore apply per server; a restart hands everyone a full burst.
+type postLimiter struct {
+	mu      sync.Mutex
+	buckets map[limitKey]*tokenBucket
+	pruned  time.Time
+}
+
+type limitKey struct {
+	userID     primitive.ObjectID
+	targetType string
+}
+
+type tokenBucket struct {
+	tokens float64
+	burst  float64
+	at     time.Time
+	full   time.Time
+}
+
+func newPostLimiter() *postLimiter {
+	return &postLimiter{buckets: make(map[limitKey]*tokenBucket), pruned: time.Now()}
+}
+
+var limiter = newPostLimiter()
+
+// allow takes one post from the user's bucket. When it is empty it returns
+// how long until the next post is allowed.
+func (l *postLimiter) allow(key limitKey, limit config.RateLimit) (time.Duration, bool) {
+	if limit.PerHour <= 0 {
+		return 0, true
+	}
+	perSecond := limit.PerHour / 3600
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.pruned) > 10*time.Minute {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), burst: float64(limit.Burst), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.at).Seconds()*perSecond)
+	b.at = now
+	if b.tokens < 1 {
+		return secondsToDuration((1 - b.tokens) / perSecond), false
+	}
+	b.tokens--
+	b.full = now.Add(secondsToDuration((float64(limit.Burst) - b.tokens) / perSecond))
+	return 0, true
+}
+
+// refund gives back a post taken from the user's bucket that was not made.
+func (l *postLimiter) refund(key limitKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// prune forgets buckets that have refilled completely, since a new bucket
+// starts full anyway.
+func (l *postLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if now.After(b.full) {
+			delete(l.buckets, key)
+		}
+	}
+	l.pruned = now
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// checkPostRate applies the user's posting rate limit for a kind of post.
+// Accounts younger than NewAccountAge get the stricter limits.
+func checkPostRate(ctx context.Context, userID primitive.ObjectID, targetType string) error {
+	u, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	trusted := time.Since(u.CreatedAt) >= spam.NewAccountAge
+
+	var limit config.RateLimit
+	switch {
+	case targetType == models.TargetVent && trusted:
+		limit = spam.Vents
+	case targetType == models.TargetVent:
+		limit = spam.NewVents
+	case trusted:
+		limit = spam.Replies
+	default:
+		limit = spam.NewReplies
+	}
+	if wait, ok := limiter.allow(limitKey{userID: userID, targetType: targetType}, limit); !ok {
+		return &RateLimitError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// refundPostRate gives back the post checkPostRate counted when screening
+// then refused it, so rejected posts do not use up the user's allowance.
+func refundPostRate(userID primitive.ObjectID, targetType string) {
+	limiter.refund(limitKey{userID: userID, targetType: targetType})
+}
+
+// spamStage fingerprints content and flags tag flooding and near-duplicates:
+// repeating one's own recent post is rejected, while copying someone else's
+// is held, since it is as likely to be a quote as a spam wave. Posts shorter
+// than GlobalMinWords, like "thank you", only count as repeats of one's own
+// replies on the same vent.
+type spamStage struct{}
+
+func (spamStage) Screen(ctx context.Context, s *Screening) error {
+	words := normalisedWords(s.Content)
+	s.Simhash = simhash(words)
+
+	if spam.MaxTags > 0 && len(s.Tags) > spam.MaxTags {
+		s.flag(models.FilterReject, fmt.Sprintf("more than %d tags", spam.MaxTags))
+	}
+	if spam.DuplicateDistance < 0 || len(words) == 0 {
+		return nil
+	}
+
+	own, err := recentFingerprints(ctx, s.TargetType, &s.AuthorID, spam.AuthorWindow, spam.AuthorRecent)
+	if err != nil {
+		return err
+	}
+	short := len(words) < spam.GlobalMinWords
+	for _, p := range own {
+		if short && (s.TargetType != models.TargetReply || p.VentID != s.VentID) {
+			continue
+		}
+		if p.ID != s.TargetID && nearDuplicate(p.Simhash, s.Simhash) {
+			s.flag(models.FilterReject, "duplicate of your recent post")
+			return nil
+		}
+	}
+
+	if short {
+		return nil
+	}
+	recent, err := recentFingerprints(ctx, s.TargetType, nil, spam.GlobalWindow, spam.GlobalRecent)
+	if err != nil {
+		return err
+	}
+	for _, p := range recent {
+		if p.AuthorID != s.AuthorID && nearDuplicate(p.Simhash, s.Simhash) {
+			s.flag(models.FilterHold, "duplicate of a recent post")
+			return nil
+		}
+	}
+	return nil
+}
+
+func recentFingerprints(ctx context.Context, targetType string, authorID *primitive.ObjectID, window time.Duration, limit int64) ([]repositories.PostFingerprint, error) {
+	since := time.Now().Add(-window)
+	if targetType == models.TargetReply {
+		return replyRepo.RecentFingerprints(ctx, authorID, since, limit)
+	}
+	return ventRepo.RecentFingerprints(ctx, authorID, since, limit)
+}
+
+func nearDuplicate(a, b int64) bool {
+	return bits.OnesCount64(uint64(a^b)) <= spam.DuplicateDistance
+}
+
+// normalisedWords splits content into words folded the same way keyword
+// filters fold them, so trivial variations do not defeat duplicate checks.
+// Apostrophes are dropped so that "don't" and "dont" are the same word.
+func normalisedWords(content string) []string {
+	var words []string
+	var word []rune
+	for _, r := range content {
+		if r == '\'' || r == '’' {
+			continue
+		}
+		if isWordRune(r) {
+			word = append(word, foldRune(r))
+			continue
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// simhash fingerprints a list of words from their overlapping shingles, so
+// that similar texts get fingerprints differing in few bits.
+func simhash(words []string) int64 {
+	if len(words) == 0 {
+		return 0
+	}
+	shingles := []string{strings.Join(words, " ")}
+	if len(words) > shingleSize {
+		shingles = shingles[:0]
+		for i := 0; i+shingleSize <= len(words); i++ {
+			shingles = append(shingles, strings.Join(words[i:i+shingleSize], " "))
+		}
+	}
+
+	var votes [64]int
+	for _, sh := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(sh))
+		sum := h.Sum64()
+		for i := range votes {
+			if sum&(1<<uint(i)) != 0 {
+				votes[i]++
+			} else {
+				votes[i]--
+			}
+		}
+	}
+	var out uint64
+	for i, v := range votes {
+		if v > 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return int64(out)
+}
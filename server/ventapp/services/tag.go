@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrBannedTag is returned when content uses a tag an admin has banned.
+	// The wrapping error names the tag.
+	ErrBannedTag = errors.New("tag is not allowed")
+	// ErrInvalidTag is returned for tag names that are empty once normalised
+	// and for merges that cannot be carried out.
+	ErrInvalidTag = errors.New("invalid tag")
+)
+
+var (
+	tagRepo       = repositories.NewTagRepository()
+	tagFollowRepo = repositories.NewTagFollowRepository()
+)
+
+// tagNormaliser turns what users type into stored tag names.
+type tagNormaliser struct {
+	maxLength int
+	synonyms  map[string]string
+}
+
+func newTagNormaliser(cfg config.TagConfig) *tagNormaliser {
+	n := &tagNormaliser{maxLength: cfg.MaxLength, synonyms: make(map[string]string, len(cfg.Synonyms))}
+	for from, to := range cfg.Synonyms {
+		if from, to := n.clean(from), n.clean(to); from != "" && to != "" && from != to {
+			n.synonyms[from] = to
+		}
+	}
+	return n
+}
+
+var tagging = newTagNormaliser(config.DefaultConfig().Tags)
+
+// clean folds compatibility characters and case, joins words with hyphens
+// and drops punctuation, so "#Exam Week", "exam_week" and " EXAM-week "
+// are all "exam-week". '+' and '#' are kept after the first character for
+// names like "c++" and "c#".
+func (n *tagNormaliser) clean(raw string) string {
+	var out []rune
+	gap := false
+	for _, r := range strings.ToLower(norm.NFKC.String(raw)) {
+		switch {
+		case isWordRune(r) || (len(out) > 0 && (r == '+' || r == '#')):
+			if gap && len(out) > 0 {
+				out = append(out, '-')
+			}
+			gap = false
+			out = append(out, r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			gap = true
+		}
+	}
+	if n.maxLength > 0 && len(out) > n.maxLength {
+		out = []rune(strings.TrimRight(string(out[:n.maxLength]), "-"))
+	}
+	return string(out)
+}
+
+// normalise cleans a tag and maps it through the configured synonyms.
+func (n *tagNormaliser) normalise(raw string) string {
+	name := n.clean(raw)
+	if to, ok := n.synonyms[name]; ok {
+		return to
+	}
+	return name
+}
+
+// NormaliseTag returns the name a tag is stored under, following admin merges.
+// Tags that are not in the directory yet are returned normalised.
+func NormaliseTag(ctx context.Context, raw string) (string, error) {
+	names, err := normaliseTags(ctx, []string{raw})
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", ErrInvalidTag
+	}
+	return names[0], nil
+}
+
+// normaliseTags returns the distinct names the tags are stored under, in the
+// order given, dropping tags that normalise to nothing. It returns
+// ErrBannedTag if any of them is banned.
+func normaliseTags(ctx context.Context, raw []string) ([]string, error) {
+	names := distinctTags(raw, tagging.normalise)
+	if len(names) == 0 {
+		return names, nil
+	}
+	dir, err := tagRepo.FindByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	// merges are never chained, but the tag merged into may since have been
+	// banned
+	var targets []string
+	for _, name := range names {
+		if t, ok := dir[name]; ok && t.MergedInto != "" {
+			if _, known := dir[t.MergedInto]; !known {
+				targets = append(targets, t.MergedInto)
+			}
+		}
+	}
+	if len(targets) > 0 {
+		more, err := tagRepo.FindByNames(ctx, targets)
+		if err != nil {
+			return nil, err
+		}
+		for name, t := range more {
+			dir[name] = t
+		}
+	}
+
+	var banned error
+	names = distinctTags(names, func(name string) string {
+		if t, ok := dir[name]; ok && t.MergedInto != "" {
+			name = t.MergedInto
+		}
+		if t, ok := dir[name]; ok && t.Banned {
+			banned = fmt.Errorf("%w: %s", ErrBannedTag, name)
+		}
+		return name
+	})
+	if banned != nil {
+		return nil, banned
+	}
+	return names, nil
+}
+
+func distinctTags(tags []string, mapTag func(string) string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name := mapTag(tag)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// retagged updates directory usage counts after a vent's tags change from
+// before to after. Either may be nil.
+func retagged(ctx context.Context, before, after []string) {
+	had := make(map[string]bool, len(before))
+	for _, t := range before {
+		had[t] = true
+	}
+	var added []string
+	for _, t := range after {
+		if had[t] {
+			delete(had, t)
+		} else {
+			added = append(added, t)
+		}
+	}
+	removed := make([]string, 0, len(had))
+	for t := range had {
+		removed = append(removed, t)
+	}
+
+	if err := tagRepo.IncrementVentCounts(ctx, added, 1); err != nil {
+		log.Printf("tag count update failed for %v: %v", added, err)
+	}
+	if err := tagRepo.IncrementVentCounts(ctx, removed, -1); err != nil {
+		log.Printf("tag count update failed for %v: %v", removed, err)
+	}
+}
+
+// normaliseVentTags stores the tags of vents posted before tags were
+// normalised under the names they are looked up by, and recounts the
+// directory from them.
+func normaliseVentTags(ctx context.Context) error {
+	tags, err := ventRepo.DistinctTags(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tagging.normalise(tag)
+	}
+	dir, err := tagRepo.FindByNames(ctx, names)
+	if err != nil {
+		return err
+	}
+	for i, tag := range tags {
+		name := names[i]
+		if t, ok := dir[name]; ok && t.MergedInto != "" {
+			name = t.MergedInto
+		}
+		switch {
+		case name == "":
+			err = ventRepo.RemoveTag(ctx, tag)
+		case name != tag:
+			err = ventRepo.ReplaceTag(ctx, tag, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	counts, err := ventRepo.CountTags(ctx)
+	if err != nil {
+		return err
+	}
+	counted := make([]string, 0, len(counts))
+	for name := range counts {
+		counted = append(counted, name)
+	}
+	if dir, err = tagRepo.FindByNames(ctx, counted); err != nil {
+		return err
+	}
+	// banned and merged tags keep no vents
+	for name, t := range dir {
+		if !t.Active() {
+			delete(counts, name)
+		}
+	}
+	return tagRepo.SetVentCounts(ctx, counts)
+}
+
+// Tags returns one page of the tag directory, most used first. Listing banned
+// or merged tags requires PermModerateContent globally.
+func Tags(ctx context.Context, viewerID *primitive.ObjectID, f repositories.TagFilter, after *repositories.Cursor, limit int64) (models.Page[models.Tag], error) {
+	if f.Status != repositories.TagsActive {
+		if viewerID == nil {
+			return models.Page[models.Tag]{}, ErrForbidden
+		}
+		if err := requireGlobalModerator(ctx, *viewerID); err != nil {
+			return models.Page[models.Tag]{}, err
+		}
+	}
+	f.Prefix = tagging.clean(f.Prefix)
+	return tagRepo.FindPage(ctx, f, after, limit)
+}
+
+// Tag returns a tag's directory entry. Merged tags resolve to the tag they
+// were merged into; banned tags are not found.
+func Tag(ctx context.Context, raw string) (*models.Tag, error) {
+	name, err := NormaliseTag(ctx, raw)
+	if errors.Is(err, ErrBannedTag) {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tagRepo.FindByName(ctx, name)
+}
+
+// FollowTag adds a tag to the user's followed tags. Only tags in the
+// directory can be followed.
+func FollowTag(ctx context.Context, userID primitive.ObjectID, raw string) (*models.Tag, error) {
+	tag, err := Tag(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	created, err := tagFollowRepo.Upsert(ctx, userID, tag.Name)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		tag.FollowerCount++
+		if err := tagRepo.IncrementFollowerCount(ctx, tag.Name, 1); err != nil {
+			log.Printf("follower count update failed for tag %s: %v", tag.Name, err)
+		}
+	}
+	return tag, nil
+}
+
+// UnfollowTag removes a tag from the user's followed tags, if it is there.
+func UnfollowTag(ctx context.Context, userID primitive.ObjectID, raw string) error {
+	name, err := NormaliseTag(ctx, raw)
+	if errors.Is(err, ErrBannedTag) {
+		// banning a tag drops its followers
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	removed, err := tagFollowRepo.Delete(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if removed {
+		if err := tagRepo.IncrementFollowerCount(ctx, name, -1); err != nil {
+			log.Printf("follower count update failed for tag %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// FollowedTags returns the tags the user follows, in the order followed.
+func FollowedTags(ctx context.Context, userID primitive.ObjectID) ([]models.TagFollow, error) {
+	return tagFollowRepo.FindByUser(ctx, userID)
+}
+
+// FollowedTagNames returns the names of the tags the user follows. The slice
+// is empty rather than nil for users who follow none.
+func FollowedTagNames(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	follows, err := tagFollowRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(follows))
+	for _, f := range follows {
+		names = append(names, f.Tag)
+	}
+	return names, nil
+}
+
+// DescribeTag sets a tag's description, adding the tag to the directory if
+// needed. It requires PermModerateContent globally.
+func DescribeTag(ctx context.Context, actorID primitive.ObjectID, raw, description string) (*models.Tag, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	name, err := NormaliseTag(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := tagRepo.SetDescription(ctx, name, strings.TrimSpace(description), actorID); err != nil {
+		return nil, err
+	}
+	return tagRepo.FindByName(ctx, name)
+}
+
+// BanTag bans a tag: it is stripped from every vent, its followers are
+// dropped, and vents using it are refused. It requires PermModerateContent
+// globally.
+func BanTag(ctx context.Context, actorID primitive.ObjectID, raw string) (*models.Tag, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	name := tagging.normalise(raw)
+	if name == "" {
+		return nil, ErrInvalidTag
+	}
+	if err := tagRepo.SetBanned(ctx, name, true, actorID); err != nil {
+		return nil, err
+	}
+	if err := ventRepo.RemoveTag(ctx, name); err != nil {
+		return nil, err
+	}
+	if err := tagFollowRepo.DeleteByTag(ctx, name); err != nil {
+		return nil, err
+	}
+	return tagRepo.FindByName(ctx, name)
+}
+
+// UnbanTag lets a banned tag be used again. Vents it was stripped from are not
+// retagged. It requires PermModerateContent globally.
+func UnbanTag(ctx context.Context, actorID primitive.ObjectID, raw string) (*models.Tag, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	name := tagging.normalise(raw)
+	tag, err := tagRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !tag.Banned {
+		return tag, nil
+	}
+	if err := tagRepo.SetBanned(ctx, name, false, actorID); err != nil {
+		return nil, err
+	}
+	return tagRepo.FindByName(ctx, name)
+}
+
+// MergeTag merges one tag into another: vents, followers and mutes of the
+// first move to the second, and the first is stored as the second from then
+// on. It requires PermModerateContent globally.
+func MergeTag(ctx context.Context, actorID primitive.ObjectID, rawFrom, rawInto string) (*models.Tag, error) {
+	if err := requireGlobalModerator(ctx, actorID); err != nil {
+		return nil, err
+	}
+	from := tagging.normalise(rawFrom)
+	into, err := NormaliseTag(ctx, rawInto)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" || from == into {
+		return nil, fmt.Errorf("%w: a tag cannot be merged into itself", ErrInvalidTag)
+	}
+	if t, err := tagRepo.FindByName(ctx, from); err == nil && !t.Active() {
+		return nil, fmt.Errorf("%w: %s is already banned or merged", ErrInvalidTag, from)
+	} else if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if err := tagRepo.MarkMerged(ctx, from, into, actorID); err != nil {
+		return nil, err
+	}
+	if err := ventRepo.ReplaceTag(ctx, from, into); err != nil {
+		return nil, err
+	}
+	followers, err := tagFollowRepo.Move(ctx, from, into)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutedTagRepo.Move(ctx, from, into); err != nil {
+		return nil, err
+	}
+	vents, err := ventRepo.CountTag(ctx, into)
+	if err != nil {
+		return nil, err
+	}
+	if err := tagRepo.SetCounts(ctx, into, vents, followers); err != nil {
+		return nil, err
+	}
+	return tagRepo.FindByName(ctx, into)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/telegram"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxTrackedChats is how many chats the bot remembers the last message time
+// of before forgetting those it may message again already.
+const maxTrackedChats = 1024
+
+var bot = config.DefaultConfig().Telegram
+
+// telegramBot is the Telegram channel; nil until UseTelegram is called.
+var telegramBot *telegramChannel
+
+// UseTelegram sends notifications through the bot client to users who log in
+// with Telegram. It is called at startup, before any notification is sent.
+func UseTelegram(client telegram.BotClient) {
+	telegramBot = &telegramChannel{client: client, setBlocked: userRepo.SetBotBlocked, nextChat: map[int64]time.Time{}}
+	RegisterChannel(telegramBot)
+}
+
+// telegramChannel sends notifications as bot messages, spacing them out to
+// stay under the Bot API's rate limits. setBlocked records users found to
+// have blocked the bot.
+type telegramChannel struct {
+	client     telegram.BotClient
+	setBlocked func(ctx context.Context, telegramID int64, blocked bool) error
+
+	mu       sync.Mutex
+	next     time.Time
+	nextChat map[int64]time.Time
+}
+
+func (*telegramChannel) Name() string { return models.ChannelTelegram }
+
+// Send messages the user a notification with a link to its vent. Users
+// without a Telegram account or who blocked the bot are skipped, and a user
+// found to have blocked it is skipped from then on, until they start the bot
+// again.
+func (t *telegramChannel) Send(ctx context.Context, u *models.User, n models.Notification) error {
+	if u.TelegramID == 0 || u.BotBlocked {
+		return nil
+	}
+	m := telegram.Message{ChatID: u.TelegramID, Text: n.Message()}
+	if n.VentID != nil {
+		m.LinkText = "Open in UniQ&A"
+		m.LinkURL = ventLink(*n.VentID)
+	}
+	err := t.send(ctx, m)
+	if errors.Is(err, telegram.ErrBlocked) {
+		return t.setBlocked(ctx, u.TelegramID, true)
+	}
+	return err
+}
+
+// send delivers m within the bot's rate limits. When the API still rate
+// limits the bot, every message is held back as long as it asks, and m is
+// retried once if that is no longer than MaxRetryAfter.
+func (t *telegramChannel) send(ctx context.Context, m telegram.Message) error {
+	if err := t.wait(ctx, m.ChatID); err != nil {
+		return err
+	}
+	err := t.client.SendMessage(ctx, m)
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter == 0 {
+		return err
+	}
+	t.holdBack(apiErr.RetryAfter)
+	if apiErr.RetryAfter > bot.MaxRetryAfter {
+		return err
+	}
+	if err := t.wait(ctx, m.ChatID); err != nil {
+		return err
+	}
+	return t.client.SendMessage(ctx, m)
+}
+
+// wait reserves the earliest time a message to the chat keeps to the bot's
+// overall and per-chat rates, and sleeps until then.
+func (t *telegramChannel) wait(ctx context.Context, chatID int64) error {
+	t.mu.Lock()
+	now := time.Now()
+	at := now
+	if t.next.After(at) {
+		at = t.next
+	}
+	if next := t.nextChat[chatID]; next.After(at) {
+		at = next
+	}
+	t.next = at.Add(bot.MessageGap)
+	if len(t.nextChat) >= maxTrackedChats {
+		for id, next := range t.nextChat {
+			if !next.After(now) {
+				delete(t.nextChat, id)
+			}
+		}
+	}
+	t.nextChat[chatID] = at.Add(bot.ChatGap)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// holdBack delays every message until d from now.
+func (t *telegramChannel) holdBack(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.next) {
+		t.next = until
+	}
+}
+
+// ValidTelegramWebhook reports whether secret is the webhook's secret token.
+// Without a configured secret no update is accepted.
+func ValidTelegramWebhook(secret string) bool {
+	return bot.WebhookSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(bot.WebhookSecret)) == 1
+}
+
+// HandleTelegramUpdate acts on an update Telegram delivered to the bot's
+// webhook. Blocking and unblocking the bot are recorded, /stop switches
+// Telegram off for every notification type, and /start switches it back on
+// if it was all off. Updates from chats other than private chats with the
+// bot are ignored.
+func HandleTelegramUpdate(ctx context.Context, up telegram.Update) error {
+	if telegramBot == nil {
+		return nil
+	}
+	if cm := up.MyChatMember; cm != nil && cm.Chat.Type == "private" {
+		switch cm.NewChatMember.Status {
+		case telegram.StatusKicked:
+			return userRepo.SetBotBlocked(ctx, cm.From.ID, true)
+		case telegram.StatusMember:
+			return userRepo.SetBotBlocked(ctx, cm.From.ID, false)
+		}
+		return nil
+	}
+	msg := up.Message
+	if msg == nil || msg.From == nil || msg.Chat.Type != "private" {
+		return nil
+	}
+	cmd := msg.Command()
+	if cmd != "start" && cmd != "stop" {
+		return nil
+	}
+
+	reply := telegram.Message{ChatID: msg.Chat.ID}
+	u, err := userRepo.FindByTelegramID(ctx, msg.From.ID)
+	if err == mongo.ErrNoDocuments {
+		reply.Text = "Log in to UniQ&A with this Telegram account to get your notifications here."
+		reply.LinkText, reply.LinkURL = "Open UniQ&A", notify.AppURL
+		return telegramBot.send(ctx, reply)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cmd == "stop" {
+		if err := setTelegramNotifications(ctx, u, false); err != nil {
+			return err
+		}
+		reply.Text = "You will no longer get notifications here. Send /start to turn them back on."
+		return telegramBot.send(ctx, reply)
+	}
+	if err := userRepo.SetBotBlocked(ctx, msg.From.ID, false); err != nil {
+		return err
+	}
+	if err := setTelegramNotifications(ctx, u, true); err != nil {
+		return err
+	}
+	reply.Text = "You will get replies, mentions and moderation notices here. Send /stop to turn them off, or choose what you get in your notification settings."
+	return telegramBot.send(ctx, reply)
+}
+
+// setTelegramNotifications switches Telegram off for every notification type
+// the user gets, or back to the default types if they get none over it.
+func setTelegramNotifications(ctx context.Context, u *models.User, on bool) error {
+	p, err := NotificationSettings(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if on {
+		for _, c := range p.Types {
+			if c.Telegram {
+				return nil
+			}
+		}
+	}
+	for t, c := range p.Types {
+		c.Telegram = on && models.DefaultChannels(t).Telegram
+		p.Types[t] = c
+	}
+	return prefsRepo.Save(ctx, p)
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/telegram"
+)
+
+// botResponse is a reply from the fake Bot API.
+type botResponse struct {
+	status int
+	body   string
+}
+
+var (
+	okResponse          = botResponse{http.StatusOK, `{"ok":true,"result":{}}`}
+	blockedResponse     = botResponse{http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`}
+	rateLimitedResponse = botResponse{http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`}
+)
+
+// fakeBotAPI answers sendMessage with each response in turn, repeating the
+// last, and counts the calls.
+func fakeBotAPI(t *testing.T, responses ...botResponse) (*telegram.Client, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		res := responses[min(calls, len(responses)-1)]
+		calls++
+		mu.Unlock()
+		w.WriteHeader(res.status)
+		w.Write([]byte(res.body))
+	}))
+	t.Cleanup(srv.Close)
+	return telegram.NewClient(srv.URL, "123456:secret-token"), func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+// useBotConfig sets the bot config for the test, with no gaps between
+// messages unless the test sets them.
+func useBotConfig(t *testing.T, maxRetryAfter time.Duration) {
+	prev := bot
+	bot = config.TelegramConfig{MaxRetryAfter: maxRetryAfter}
+	t.Cleanup(func() { bot = prev })
+}
+
+func newTestChannel(client telegram.BotClient) (*telegramChannel, *[]int64) {
+	var blocked []int64
+	ch := &telegramChannel{
+		client: client,
+		setBlocked: func(ctx context.Context, telegramID int64, b bool) error {
+			if b {
+				blocked = append(blocked, telegramID)
+			}
+			return nil
+		},
+		nextChat: map[int64]time.Time{},
+	}
+	return ch, &blocked
+}
+
+func TestTelegramSendMarksBlockedUsers(t *testing.T) {
+	useBotConfig(t, 30*time.Second)
+	client, calls := fakeBotAPI(t, blockedResponse)
+	ch, blocked := newTestChannel(client)
+
+	u := &models.User{TelegramID: 42}
+	if err := ch.Send(context.Background(), u, models.Notification{Type: models.NotifyReply, TargetType: models.TargetVent}); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 1 {
+		t.Errorf("got %d calls, want 1", calls())
+	}
+	if len(*blocked) != 1 || (*blocked)[0] != 42 {
+		t.Errorf("blocked = %v, want [42]", *blocked)
+	}
+
+	// users known to have blocked the bot are skipped
+	u.BotBlocked = true
+	if err := ch.Send(context.Background(), u, models.Notification{Type: models.NotifyReply}); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 1 {
+		t.Errorf("got %d calls after blocking, want 1", calls())
+	}
+}
+
+func TestTelegramSendRetriesRateLimit(t *testing.T) {
+	useBotConfig(t, 30*time.Second)
+	client, calls := fakeBotAPI(t, rateLimitedResponse, okResponse)
+	ch, _ := newTestChannel(client)
+
+	start := time.Now()
+	if err := ch.send(context.Background(), telegram.Message{ChatID: 42, Text: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 2 {
+		t.Errorf("got %d calls, want 2", calls())
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want at least the 1s asked for", waited)
+	}
+}
+
+func TestTelegramSendRetriesOnlyOnce(t *testing.T) {
+	useBotConfig(t, 30*time.Second)
+	client, calls := fakeBotAPI(t, rateLimitedResponse)
+	ch, _ := newTestChannel(client)
+
+	if err := ch.send(context.Background(), telegram.Message{ChatID: 42, Text: "hi"}); err == nil {
+		t.Fatal("no error when still rate limited")
+	}
+	if calls() != 2 {
+		t.Errorf("got %d calls, want 2", calls())
+	}
+}
+
+func TestTelegramSendHoldsBackLongRateLimit(t *testing.T) {
+	useBotConfig(t, 500*time.Millisecond)
+	client, calls := fakeBotAPI(t, rateLimitedResponse, okResponse)
+	ch, _ := newTestChannel(client)
+
+	start := time.Now()
+	if err := ch.send(context.Background(), telegram.Message{ChatID: 42, Text: "hi"}); err == nil {
+		t.Fatal("no error when the wait exceeds MaxRetryAfter")
+	}
+	if calls() != 1 {
+		t.Errorf("got %d calls, want 1", calls())
+	}
+	if ch.next.Before(start.Add(time.Second)) {
+		t.Errorf("messages held back until %s, want at least 1s after %s", ch.next, start)
+	}
+
+	// the next message, to any chat, waits out the rate limit
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := ch.send(ctx, telegram.Message{ChatID: 7, Text: "hi"}); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the wait to outlast the context", err)
+	}
+	if calls() != 1 {
+		t.Errorf("got %d calls while held back, want 1", calls())
+	}
+}
+
+func TestTelegramWaitSpacesMessages(t *testing.T) {
+	prev := bot
+	bot = config.TelegramConfig{MessageGap: 10 * time.Millisecond, ChatGap: 50 * time.Millisecond}
+	t.Cleanup(func() { bot = prev })
+	ch, _ := newTestChannel(nil)
+
+	start := time.Now()
+	for _, chat := range []int64{1, 2, 1} {
+		if err := ch.wait(context.Background(), chat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the second message to chat 1 waits for the per-chat gap
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("three messages took %s, want at least the 50ms chat gap", waited)
+	}
+}
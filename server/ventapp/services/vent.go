@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ventapp/server/ventapp/config"
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrForbidden        = errors.New("forbidden")
+	ErrEditWindowClosed = errors.New("edit window has closed")
+	ErrConflict         = errors.New("content was changed concurrently")
+)
+
+var (
+	editWindow   = config.DefaultConfig().EditWindow
+	revisionRepo = repositories.NewVentRevisionRepository()
+)
+
+// CreateVent saves a new vent by its author after applying their posting rate
+// limit, normalising its tags and screening its content.
+// Vents by shadow-banned authors are shadowed, and vents screening holds are
+// saved under review and queued for moderators. Users it mentions are
+// notified.
+func CreateVent(ctx context.Context, vent *models.Vent) (*Screening, error) {
+	shadowedUntil, err := CheckCanPost(ctx, vent.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if vent.Tags, err = normaliseTags(ctx, vent.Tags); err != nil {
+		return nil, err
+	}
+	if err := checkPostRate(ctx, vent.AuthorID, models.TargetVent); err != nil {
+		return nil, err
+	}
+	s, err := screen(ctx, &Screening{
+		AuthorID:   vent.AuthorID,
+		TargetType: models.TargetVent,
+		Content:    vent.Content,
+		Tags:       vent.Tags,
+		Scope:      vent.Scope(),
+	})
+	if err != nil {
+		refundPostRate(vent.AuthorID, models.TargetVent)
+		return nil, err
+	}
+
+	vent.Content = s.Content
+	vent.Tags = s.Tags
+	vent.Simhash = s.Simhash
+	vent.ShadowedUntil = shadowedUntil
+	vent.UnderReview = s.Held()
+	if err := ventRepo.Create(ctx, vent); err != nil {
+		return nil, err
+	}
+	if vent.HotScore, err = ventRepo.RefreshHotScore(ctx, vent.ID, hotEpoch, ranking.HotGravity, ranking.ReplyWeight); err != nil {
+		log.Printf("hot score refresh failed for vent %s: %v", vent.ID.Hex(), err)
+	}
+	retagged(ctx, nil, vent.Tags)
+	if s.Held() {
+		holdForReview(ctx, s, vent.ID, vent, nil)
+	} else if shadowedUntil == nil {
+		notifyMentions(ctx, vent.AuthorID, models.TargetVent, vent.ID, vent.ID, vent.Content)
+	}
+	return s, nil
+}
+
+// EditVent lets the author change a vent's content and tags within the edit
+// window. The replaced version is kept as a revision. Nil arguments leave the
+// corresponding field unchanged. The edited vent is screened like a new one.
+func EditVent(ctx context.Context, actorID, ventID primitive.ObjectID, content *string, tags *[]string) (*models.Vent, *Screening, error) {
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vent.AuthorID != actorID {
+		return nil, nil, ErrForbidden
+	}
+	if editWindow > 0 && time.Since(vent.CreatedAt) > editWindow {
+		return nil, nil, ErrEditWindowClosed
+	}
+	if _, err := CheckCanPost(ctx, actorID); err != nil {
+		return nil, nil, err
+	}
+
+	newContent, newTags := vent.Content, vent.Tags
+	if content != nil {
+		newContent = *content
+	}
+	if tags != nil {
+		if newTags, err = normaliseTags(ctx, *tags); err != nil {
+			return nil, nil, err
+		}
+	}
+	s, err := screen(ctx, &Screening{
+		AuthorID:   actorID,
+		TargetType: models.TargetVent,
+		TargetID:   vent.ID,
+		Content:    newContent,
+		Tags:       newTags,
+		Scope:      vent.Scope(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	newContent, newTags = s.Content, s.Tags
+
+	rev := &models.VentRevision{
+		VentID:   vent.ID,
+		Version:  vent.EditCount + 1,
+		Content:  vent.Content,
+		Tags:     vent.Tags,
+		EditedBy: actorID,
+	}
+	if err := revisionRepo.Create(ctx, rev); err != nil {
+		return nil, nil, err
+	}
+	if err := ventRepo.UpdateContent(ctx, vent.ID, vent.UpdatedAt, newContent, newTags, s.Simhash); err != nil {
+		_ = revisionRepo.Delete(ctx, rev.ID)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, ErrConflict
+		}
+		return nil, nil, err
+	}
+	retagged(ctx, vent.Tags, newTags)
+	if s.Held() {
+		if err := holdEdited(ctx, s, vent.ID, vent, vent); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	edited, err := ventRepo.FindByID(ctx, vent.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return edited, s, nil
+}
+
+// DeleteVent soft-deletes a vent. Authors may delete their own vents;
+// anyone else needs PermModerateContent in the vent's scope, and their delete
+// is recorded in the moderation log.
+func DeleteVent(ctx context.Context, actorID, ventID primitive.ObjectID, reason string) (*models.Vent, error) {
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	moderated := vent.AuthorID != actorID
+	if moderated {
+		allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+	}
+	if err := ventRepo.SoftDelete(ctx, vent.ID, actorID, reason); err != nil {
+		return nil, err
+	}
+	retagged(ctx, vent.Tags, nil)
+
+	if moderated {
+		action := contentAction(models.ModActionDelete, &actorID, models.TargetVent, vent.ID, vent.AuthorID, vent, reason)
+		action.Before = snapshot(vent)
+		action.After = contentSnapshot(ctx, models.TargetVent, vent.ID)
+		logAction(ctx, action)
+	}
+	return vent, nil
+}
+
+// RestoreVent undoes a soft delete. It requires PermModerateContent in the
+// vent's scope.
+func RestoreVent(ctx context.Context, actorID, ventID primitive.ObjectID, reason string) (*models.Vent, error) {
+	vent, err := ventRepo.FindAnyByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	if err := ventRepo.Restore(ctx, vent.ID); err != nil {
+		return nil, err
+	}
+
+	restored, err := ventRepo.FindByID(ctx, vent.ID)
+	if err != nil {
+		return nil, err
+	}
+	retagged(ctx, nil, restored.Tags)
+	action := contentAction(models.ModActionUndelete, &actorID, models.TargetVent, vent.ID, vent.AuthorID, vent, reason)
+	action.Before = snapshot(vent)
+	action.After = snapshot(restored)
+	logAction(ctx, action)
+	return restored, nil
+}
+
+// VentRevisions returns a vent's edit history, newest first. It requires
+// PermModerateContent in the vent's scope.
+func VentRevisions(ctx context.Context, actorID, ventID primitive.ObjectID) ([]models.VentRevision, error) {
+	vent, err := ventRepo.FindAnyByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := Can(ctx, actorID, models.PermModerateContent, vent.Scope())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return revisionRepo.FindByVent(ctx, vent.ID)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ventapp/server/ventapp/config"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ViewCounter de-duplicates vent views per viewer and buffers the resulting
+// increments in memory so reading a vent does not cost a write.
+type ViewCounter struct {
+	cfg     config.ViewConfig
+	mu      sync.Mutex
+	seen    map[viewKey]time.Time
+	pending map[primitive.ObjectID]int
+}
+
+type viewKey struct {
+	ventID primitive.ObjectID
+	viewer string
+}
+
+func NewViewCounter(cfg config.ViewConfig) *ViewCounter {
+	return &ViewCounter{
+		cfg:     cfg,
+		seen:    make(map[viewKey]time.Time),
+		pending: make(map[primitive.ObjectID]int),
+	}
+}
+
+// Views is the process-wide view counter used by the vent handlers.
+var Views = NewViewCounter(config.DefaultConfig().Views)
+
+// Record counts a view of the vent by viewer (a user id or an anonymous
+// fingerprint) unless the same viewer was counted within the window. It
+// reports whether the view was counted.
+func (vc *ViewCounter) Record(ventID primitive.ObjectID, viewer string) bool {
+	now := time.Now()
+	key := viewKey{ventID: ventID, viewer: viewer}
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if last, ok := vc.seen[key]; ok && now.Sub(last) < vc.cfg.Window {
+		return false
+	}
+	vc.seen[key] = now
+	vc.pending[ventID]++
+	return true
+}
+
+// Flush writes buffered counts to the database and forgets viewers whose
+// window has passed. Counts that fail to write are kept for the next flush.
+func (vc *ViewCounter) Flush(ctx context.Context) error {
+	vc.mu.Lock()
+	pending := vc.pending
+	vc.pending = make(map[primitive.ObjectID]int)
+	cutoff := time.Now().Add(-vc.cfg.Window)
+	for key, at := range vc.seen {
+		if at.Before(cutoff) {
+			delete(vc.seen, key)
+		}
+	}
+	vc.mu.Unlock()
+
+	if err := ventRepo.IncrementViews(ctx, pending); err != nil {
+		vc.mu.Lock()
+		for id, n := range pending {
+			vc.pending[id] += n
+		}
+		vc.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// finalFlushTimeout bounds the flush Run makes on its way out.
+const finalFlushTimeout = 10 * time.Second
+
+// Run flushes every FlushInterval until ctx is done, then flushes once more
+// before returning, so callers can wait for it before disconnecting.
+func (vc *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(vc.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+			defer cancel()
+			if err := vc.Flush(flushCtx); err != nil {
+				log.Printf("final view flush failed: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := vc.Flush(ctx); err != nil {
+				log.Printf("view flush failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"ventapp/server/ventapp/models"
+	"ventapp/server/ventapp/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var voteRepo = repositories.NewVoteRepository()
+
+// ParseVote maps the up/down/none vote strings to a vote value.
+func ParseVote(s string) (int, bool) {
+	switch s {
+	case "up":
+		return 1, true
+	case "down":
+		return -1, true
+	case "none":
+		return 0, true
+	}
+	return 0, false
+}
+
+// voteDeltas converts a change from prev to value into counter deltas.
+func voteDeltas(prev, value int) (up, down int) {
+	switch prev {
+	case 1:
+		up--
+	case -1:
+		down--
+	}
+	switch value {
+	case 1:
+		up++
+	case -1:
+		down++
+	}
+	return up, down
+}
+
+// setVote records the vote and returns the counter deltas it implies. Setting
+// the same vote twice yields zero deltas.
+func setVote(ctx context.Context, userID primitive.ObjectID, targetType string, targetID primitive.ObjectID, value int) (up, down int, err error) {
+	if _, err := CheckCanPost(ctx, userID); err != nil {
+		return 0, 0, err
+	}
+	prev, err := voteRepo.Set(ctx, userID, targetType, targetID, value)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent first vote by the same user inserted the record; retry as an update
+		prev, err = voteRepo.Set(ctx, userID, targetType, targetID, value)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	up, down = voteDeltas(prev, value)
+	return up, down, nil
+}
+
+// VoteVent sets the user's vote on a vent (1, -1, or 0 to clear) and keeps the
+// vent's counters, hot score and trending activity in step. The votes
+// collection is the source of truth: if the counter update fails the counters
+// are recounted from it. New upvotes notify the vent's author.
+func VoteVent(ctx context.Context, userID, ventID primitive.ObjectID, value int) (*models.Vent, error) {
+	up, down, err := setVote(ctx, userID, models.TargetVent, ventID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if up != 0 || down != 0 {
+		if err := ventRepo.ApplyVoteDelta(ctx, ventID, up, down); err != nil {
+			log.Printf("vote counter update failed for vent %s, recounting: %v", ventID.Hex(), err)
+			if err := ReconcileVentVotes(ctx, ventID); err != nil {
+				return nil, err
+			}
+		}
+		if err := RefreshHotScore(ctx, ventID); err != nil {
+			log.Printf("hot score refresh failed for vent %s: %v", ventID.Hex(), err)
+		}
+		if err := RecordVoteActivity(ctx, ventID, up-down); err != nil {
+			log.Printf("vote activity update failed for vent %s: %v", ventID.Hex(), err)
+		}
+	}
+
+	vent, err := ventRepo.FindByID(ctx, ventID)
+	if err != nil {
+		return nil, err
+	}
+	if up > 0 {
+		notifier.Notify(ctx, models.Notification{
+			UserID:     vent.AuthorID,
+			Type:       models.NotifyVote,
+			TargetType: models.TargetVent,
+			TargetID:   vent.ID,
+			VentID:     &vent.ID,
+		}, &userID)
+	}
+	return vent, nil
+}
+
+// ReconcileVentVotes recounts a vent's votes from the votes collection.
+func ReconcileVentVotes(ctx context.Context, ventID primitive.ObjectID) error {
+	up, down, err := voteRepo.Count(ctx, models.TargetVent, ventID)
+	if err != nil {
+		return err
+	}
+	return ventRepo.SetVoteCounts(ctx, ventID, up, down)
+}
+
+// UserVote returns the user's current vote on a target, or 0.
+func UserVote(ctx context.Context, userID primitive.ObjectID, targetType string, targetID primitive.ObjectID) (int, error) {
+	return voteRepo.Find(ctx, userID, targetType, targetID)
+}
@@ -0,0 +1,190 @@
+// Package telegram talks to the Telegram Bot API: it sends the bot's
+// messages and describes the updates Telegram delivers to its webhook.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the address of the Telegram Bot API.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// ErrBlocked is returned when a message cannot be delivered because the user
+// blocked the bot or deleted their account.
+var ErrBlocked = errors.New("telegram user blocked the bot")
+
+// APIError is an error the Bot API reported. RetryAfter is set when the bot
+// was rate limited and says how long to wait before sending again.
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+// Message is a text message from the bot. LinkText and LinkURL, when set,
+// add a button that opens the link.
+type Message struct {
+	ChatID   int64
+	Text     string
+	LinkText string
+	LinkURL  string
+}
+
+// BotClient sends messages as the bot.
+type BotClient interface {
+	SendMessage(ctx context.Context, m Message) error
+}
+
+// Client is a BotClient that calls the Bot API over HTTP. APIURL can point at
+// a local server for tests.
+type Client struct {
+	APIURL string
+	Token  string
+	HTTP   *http.Client
+}
+
+// NewClient returns a client for the bot with the given token.
+func NewClient(apiURL, token string) *Client {
+	return &Client{APIURL: apiURL, Token: token, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type inlineButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]inlineButton `json:"inline_keyboard"`
+}
+
+type sendMessageRequest struct {
+	ChatID                int64           `json:"chat_id"`
+	Text                  string          `json:"text"`
+	DisableWebPagePreview bool            `json:"disable_web_page_preview"`
+	ReplyMarkup           *inlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// SendMessage sends m. It returns ErrBlocked when the user can no longer be
+// messaged and an *APIError for other failures the API reports.
+func (c *Client) SendMessage(ctx context.Context, m Message) error {
+	req := sendMessageRequest{ChatID: m.ChatID, Text: m.Text, DisableWebPagePreview: true}
+	if m.LinkURL != "" {
+		req.ReplyMarkup = &inlineKeyboard{InlineKeyboard: [][]inlineButton{{{Text: m.LinkText, URL: m.LinkURL}}}}
+	}
+	return c.call(ctx, "sendMessage", req)
+}
+
+// call invokes a Bot API method with a JSON body.
+func (c *Client) call(ctx context.Context, method string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(c.APIURL, "/") + "/bot" + c.Token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		// the URL carries the token, which must not end up in logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("telegram: %s: %w", method, urlErr.Err)
+		}
+		return err
+	}
+	defer res.Body.Close()
+
+	var out apiResponse
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return fmt.Errorf("telegram: %s: %s", method, res.Status)
+	}
+	if out.OK {
+		return nil
+	}
+	if out.ErrorCode == http.StatusForbidden {
+		return ErrBlocked
+	}
+	apiErr := &APIError{Code: out.ErrorCode, Description: out.Description}
+	if out.Parameters != nil {
+		apiErr.RetryAfter = time.Duration(out.Parameters.RetryAfter) * time.Second
+	}
+	return apiErr
+}
+
+// Update is what Telegram delivers to the bot's webhook. Only the kinds of
+// update the bot handles are described.
+type Update struct {
+	UpdateID     int64              `json:"update_id"`
+	Message      *IncomingMessage   `json:"message"`
+	MyChatMember *ChatMemberUpdated `json:"my_chat_member"`
+}
+
+// User is a Telegram user.
+type User struct {
+	ID int64 `json:"id"`
+}
+
+// Chat is the chat a message or membership change happened in. Private
+// chats with a user have the user's id.
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// IncomingMessage is a message sent to the bot.
+type IncomingMessage struct {
+	From *User  `json:"from"`
+	Chat Chat   `json:"chat"`
+	Text string `json:"text"`
+}
+
+// Command returns the bot command the message starts with, without its
+// slash or bot name, or "" if it is not a command.
+func (m IncomingMessage) Command() string {
+	if !strings.HasPrefix(m.Text, "/") {
+		return ""
+	}
+	cmd, _, _ := strings.Cut(strings.Fields(m.Text)[0][1:], "@")
+	return strings.ToLower(cmd)
+}
+
+// Chat member statuses the bot acts on: a user who blocks the bot becomes
+// kicked, and one who unblocks it a member again.
+const (
+	StatusMember = "member"
+	StatusKicked = "kicked"
+)
+
+// ChatMemberUpdated reports a change in the bot's membership of a chat.
+type ChatMemberUpdated struct {
+	Chat          Chat `json:"chat"`
+	From          User `json:"from"`
+	NewChatMember struct {
+		Status string `json:"status"`
+	} `json:"new_chat_member"`
+}
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:secret-token"
+
+// fakeAPI serves the Bot API's sendMessage with the given status and body
+// and records the requests it gets.
+func fakeAPI(t *testing.T, status int, body string) (*Client, *[]sendMessageRequest) {
+	t.Helper()
+	var got []sendMessageRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+testToken+"/sendMessage" {
+			t.Errorf("request to %s", r.URL.Path)
+		}
+		var req sendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		got = append(got, req)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL+"/", testToken), &got
+}
+
+func TestSendMessage(t *testing.T) {
+	c, got := fakeAPI(t, http.StatusOK, `{"ok":true,"result":{}}`)
+	err := c.SendMessage(context.Background(), Message{ChatID: 42, Text: "hi", LinkText: "Open", LinkURL: "https://example.edu/post/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*got))
+	}
+	req := (*got)[0]
+	if req.ChatID != 42 || req.Text != "hi" || !req.DisableWebPagePreview {
+		t.Errorf("request = %+v", req)
+	}
+	if req.ReplyMarkup == nil || req.ReplyMarkup.InlineKeyboard[0][0] != (inlineButton{Text: "Open", URL: "https://example.edu/post/1"}) {
+		t.Errorf("reply markup = %+v", req.ReplyMarkup)
+	}
+}
+
+func TestSendMessageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(t *testing.T, err error)
+	}{
+		{
+			name:   "blocked",
+			status: http.StatusForbidden,
+			body:   `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`,
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrBlocked) {
+					t.Errorf("err = %v, want ErrBlocked", err)
+				}
+			},
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			body:   `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`,
+			check: func(t *testing.T, err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("err = %v, want *APIError", err)
+				}
+				if apiErr.Code != http.StatusTooManyRequests || apiErr.RetryAfter != 3*time.Second {
+					t.Errorf("err = %+v", apiErr)
+				}
+			},
+		},
+		{
+			name:   "other API error",
+			status: http.StatusBadRequest,
+			body:   `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`,
+			check: func(t *testing.T, err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest || apiErr.RetryAfter != 0 {
+					t.Errorf("err = %v", err)
+				}
+			},
+		},
+		{
+			name:   "body not JSON",
+			status: http.StatusBadGateway,
+			body:   `<html>bad gateway</html>`,
+			check: func(t *testing.T, err error) {
+				if err == nil || !strings.Contains(err.Error(), "502 Bad Gateway") {
+					t.Errorf("err = %v, want the status", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := fakeAPI(t, tt.status, tt.body)
+			err := c.SendMessage(context.Background(), Message{ChatID: 42, Text: "hi"})
+			tt.check(t, err)
+			if err != nil && strings.Contains(err.Error(), testToken) {
+				t.Errorf("error leaks the token: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransportErrorHidesToken(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := NewClient(srv.URL, testToken)
+	err := c.SendMessage(context.Background(), Message{ChatID: 42, Text: "hi"})
+	if err == nil {
+		t.Fatal("no error from a closed server")
+	}
+	if strings.Contains(err.Error(), testToken) || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error leaks the token: %v", err)
+	}
+	if !strings.Contains(err.Error(), "sendMessage") {
+		t.Errorf("error does not name the method: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to the peer
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the peer
+	pongWait = 60 * time.Second
+
+	// Send pings to peer with this period. Must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum message size allowed from peer
+	maxMessageSize = 4096
+)
+
+// NewClient wraps an upgraded connection for the given user
+func NewClient(hub *Hub, conn *websocket.Conn, userID, username string) *Client {
+	return &Client{
+		Hub:      hub,
+		ID:       conn.RemoteAddr().String() + "-" + time.Now().Format(time.RFC3339Nano),
+		UserID:   userID,
+		Username: username,
+		Socket:   conn,
+		Send:     make(chan []byte, 256),
+	}
+}
+
+// ReadPump relays typing indicators from the client to everyone else and
+// unregisters the client when the connection closes
+func (c *Client) ReadPump() {
+	defer func() {
+		c.Hub.Unregister(c)
+		c.Socket.Close()
+	}()
+
+	c.Socket.SetReadLimit(maxMessageSize)
+	c.Socket.SetReadDeadline(time.Now().Add(pongWait))
+	c.Socket.SetPongHandler(func(string) error {
+		c.Socket.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, data, err := c.Socket.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case MessageTypeTyping, MessageTypeStopTyping:
+			// never trust the sender's claimed identity
+			msg.UserID = c.UserID
+			msg.Username = c.Username
+			msg.Timestamp = time.Now()
+			c.Hub.BroadcastFrom(c.UserID, msg)
+		}
+	}
+}
+
+// WritePump sends queued messages and pings to the client, each message in
+// its own frame so clients can parse every frame as one JSON document.
+func (c *Client) WritePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Socket.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Socket.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// The hub closed the channel
+				c.Socket.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if err := c.Socket.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			c.Socket.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Socket.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
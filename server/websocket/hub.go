@@ -20,6 +20,21 @@ const (
 	MessageTypeStopTyping = "stop_typing"
 	MessageTypeUserJoined = "user_joined"
 	MessageTypeUserLeft   = "user_left"
+
+	MessageTypeVentUpdated  = "vent_updated"
+	MessageTypeVentDeleted  = "vent_deleted"
+	MessageTypeVentRestored = "vent_restored"
+	MessageTypeReplyUpdated = "reply_updated"
+	MessageTypeReplyDeleted = "reply_deleted"
+
+	MessageTypeAnswerAccepted = "answer_accepted"
+
+	MessageTypeContentUnderReview = "content_under_review"
+	MessageTypeContentRestored    = "content_restored"
+	MessageTypeCrisisSupport      = "crisis_support"
+
+	MessageTypeNotification      = "notification"
+	MessageTypeNotificationsRead = "notifications_read"
 )
 
 // Message represents a WebSocket message
@@ -49,6 +64,8 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+	// avoiders returns the users who do not want events from a user
+	avoiders func(userID string) []string
 }
 
 // NewHub creates a new WebSocket hub
@@ -176,13 +193,72 @@ func (h *Hub) Run() {
 	}
 }
 
+// Register adds a client to the hub
+func (h *Hub) Register(client *Client) {
+	h.register <- client
+}
+
+// Unregister removes a client from the hub and closes its send channel
+func (h *Hub) Unregister(client *Client) {
+	h.unregister <- client
+}
+
+// DisconnectUser closes every connection held by a user. Their read pumps
+// then fail and unregister them as usual.
+func (h *Hub) DisconnectUser(userID string) {
+	h.mu.RLock()
+	var conns []*Client
+	for client := range h.clients {
+		if client.UserID == userID {
+			conns = append(conns, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range conns {
+		client.Socket.Close()
+	}
+}
+
 // BroadcastMessage sends a message to all connected clients
 func (h *Hub) BroadcastMessage(msg Message) {
 	h.broadcastMessage(msg)
 }
 
+// SetAvoiders sets how the hub finds the users who do not want events from
+// a user, such as those who blocked them. It must be called before clients
+// connect.
+func (h *Hub) SetAvoiders(avoiders func(userID string) []string) {
+	h.avoiders = avoiders
+}
+
+// BroadcastFrom sends a message sent by a user to all connected clients
+// except those of users avoiding the sender.
+func (h *Hub) BroadcastFrom(senderID string, msg Message) {
+	if h.avoiders == nil {
+		h.broadcastMessage(msg)
+		return
+	}
+	h.BroadcastExcept(h.avoiders(senderID), msg)
+}
+
+// BroadcastExcept sends a message to all connected clients except those of
+// the given users
+func (h *Hub) BroadcastExcept(userIDs []string, msg Message) {
+	skip := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		skip[id] = true
+	}
+	h.sendTo(msg, func(client *Client) bool { return !skip[client.UserID] })
+}
+
 // BroadcastToUser sends a message to a specific user
 func (h *Hub) BroadcastToUser(userID string, msg Message) {
+	h.sendTo(msg, func(client *Client) bool { return client.UserID == userID })
+}
+
+// sendTo sends a message straight to the clients matching a predicate
+func (h *Hub) sendTo(msg Message, match func(*Client) bool) {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		log.Printf("Error marshaling message: %v", err)
@@ -193,7 +269,7 @@ func (h *Hub) BroadcastToUser(userID string, msg Message) {
 	var toRemove []*Client
 	h.mu.RLock()
 	for client := range h.clients {
-		if client.UserID == userID {
+		if match(client) {
 			select {
 			case client.Send <- data:
 				// sent